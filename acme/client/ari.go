@@ -0,0 +1,129 @@
+package client
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cpu/acmeshell/acme"
+	"github.com/cpu/acmeshell/acme/resources"
+)
+
+// ARICertID computes the ACME Renewal Information (ARI) certificate identifier
+// for the given certificate. The identifier is the base64url encoding of the
+// certificate's Authority Key Identifier keyIdentifier, a "." character, and
+// the base64url encoding of the DER encoded certificate serial number bytes.
+//
+// See https://datatracker.ietf.org/doc/html/rfc9773#section-4.1
+func ARICertID(cert *x509.Certificate) (string, error) {
+	if cert == nil {
+		return "", errors.New("ARICertID: certificate must not be nil")
+	}
+	if len(cert.AuthorityKeyId) == 0 {
+		return "", errors.New("ARICertID: certificate has no Authority Key Identifier")
+	}
+	if cert.SerialNumber == nil {
+		return "", errors.New("ARICertID: certificate has no serial number")
+	}
+
+	// The serial number must be encoded as the bytes of the DER INTEGER. For
+	// positive serials with the high bit set that means a leading zero byte.
+	serial := cert.SerialNumber.Bytes()
+	if len(serial) == 0 || serial[0]&0x80 != 0 {
+		serial = append([]byte{0x00}, serial...)
+	}
+
+	return fmt.Sprintf("%s.%s",
+		base64.RawURLEncoding.EncodeToString(cert.AuthorityKeyId),
+		base64.RawURLEncoding.EncodeToString(serial)), nil
+}
+
+// ARICertIDFromPEM computes the ARI certificate identifier for the first
+// certificate in the provided PEM data. When given a PEM certificate chain this
+// will be the end-entity certificate.
+func ARICertIDFromPEM(pemBytes []byte) (string, error) {
+	for {
+		var block *pem.Block
+		block, pemBytes = pem.Decode(pemBytes)
+		if block == nil {
+			return "", errors.New("ARICertIDFromPEM: no PEM CERTIFICATE block found")
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return "", fmt.Errorf("ARICertIDFromPEM: %w", err)
+		}
+		return ARICertID(cert)
+	}
+}
+
+// OrderARICertID fetches the certificate for the given valid Order and returns
+// its ARI certificate identifier.
+func (c *Client) OrderARICertID(order *resources.Order) (string, error) {
+	pemBytes, err := c.GetCertificate(order)
+	if err != nil {
+		return "", err
+	}
+	return ARICertIDFromPEM(pemBytes)
+}
+
+// RenewalInfo fetches the ACME Renewal Information for the given ARI
+// certificate identifier from the ACME server's renewalInfo endpoint.
+//
+// If the server sent a Retry-After header the last time renewal information was
+// fetched for the certID, and that time has not yet passed, the cached
+// RenewalInfo is returned without making a request unless refresh is true.
+//
+// See https://datatracker.ietf.org/doc/html/rfc9773#section-4.2
+func (c *Client) RenewalInfo(certID string, refresh bool) (*resources.RenewalInfo, error) {
+	certID = strings.TrimSpace(certID)
+	if certID == "" {
+		return nil, errors.New("RenewalInfo: certID must not be empty")
+	}
+
+	if cached, ok := c.renewalInfo[certID]; ok && !refresh {
+		if time.Now().Before(cached.RetryAfter) {
+			log.Printf("Using cached renewal info for %q until %s\n",
+				certID, cached.RetryAfter.Format(time.RFC3339))
+			return cached, nil
+		}
+	}
+
+	renewalInfoURL, ok := c.GetEndpointURL(acme.RENEWAL_INFO_ENDPOINT)
+	if !ok {
+		return nil, fmt.Errorf(
+			"RenewalInfo: ACME server missing %q endpoint in directory",
+			acme.RENEWAL_INFO_ENDPOINT)
+	}
+	targetURL := strings.TrimSuffix(renewalInfoURL, "/") + "/" + certID
+
+	resp, err := c.GetURL(targetURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := CheckResponse(resp, http.StatusOK); err != nil {
+		return nil, fmt.Errorf("RenewalInfo: %w", err)
+	}
+	respOb := resp.Response
+
+	var info resources.RenewalInfo
+	if err := json.Unmarshal(resp.RespBody, &info); err != nil {
+		return nil, fmt.Errorf("RenewalInfo: server returned invalid JSON: %s", err)
+	}
+
+	if delay, ok := retryAfter(respOb); ok {
+		info.RetryAfter = time.Now().Add(delay)
+	}
+	c.renewalInfo[certID] = &info
+	return &info, nil
+}
@@ -0,0 +1,229 @@
+package client
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/cpu/acmeshell/acme/resources"
+	"github.com/cpu/acmeshell/net"
+)
+
+// CertificateChain is a PEM certificate chain offered by the ACME server for an
+// Order. The first certificate is the end-entity certificate and the remaining
+// certificates are the intermediates (and optionally a root) that it chains to.
+type CertificateChain struct {
+	// The URL the chain was fetched from.
+	URL string
+	// The PEM bytes of the chain as returned by the server.
+	PEM []byte
+	// The parsed certificates of the chain, in the order returned by the server.
+	Certs []*x509.Certificate
+}
+
+// Issuers returns the subject names of the certificates that issued the
+// chain's end-entity certificate. These are the subjects of every certificate
+// in the chain after the first.
+func (ch CertificateChain) Issuers() []string {
+	var issuers []string
+	for i := 1; i < len(ch.Certs); i++ {
+		issuers = append(issuers, nameString(ch.Certs[i].Subject))
+	}
+	return issuers
+}
+
+// Root returns the name of the root the chain terminates in. This is the issuer
+// name of the last certificate in the chain. For a chain that includes its
+// self-signed root this is the root's own subject.
+func (ch CertificateChain) Root() string {
+	if len(ch.Certs) == 0 {
+		return ""
+	}
+	last := ch.Certs[len(ch.Certs)-1]
+	return nameString(last.Issuer)
+}
+
+// HasIssuer returns true if the given name matches the common name (or full
+// subject) of the root or of one of the issuers in the chain.
+func (ch CertificateChain) HasIssuer(name string) bool {
+	if len(ch.Certs) == 0 {
+		return false
+	}
+	if matchesName(ch.Certs[len(ch.Certs)-1].Issuer, name) {
+		return true
+	}
+	for i := 1; i < len(ch.Certs); i++ {
+		if matchesName(ch.Certs[i].Subject, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// nameString returns the common name of the given pkix.Name, or its full string
+// representation if it has no common name.
+func nameString(name pkix.Name) string {
+	if name.CommonName != "" {
+		return name.CommonName
+	}
+	return name.String()
+}
+
+func matchesName(name pkix.Name, match string) bool {
+	return name.CommonName == match || name.String() == match
+}
+
+// GetCertificate fetches the PEM certificate chain for the given Order from the
+// ACME server. The Order must have a status of "valid" and a non-empty
+// Certificate URL. If this is successful the PEM bytes of the chain are
+// returned. Otherwise a non-nil error is returned.
+//
+// For more information on downloading certificates see
+// https://tools.ietf.org/html/rfc8555#section-7.4.2
+func (c *Client) GetCertificate(order *resources.Order) ([]byte, error) {
+	if err := checkCertificateOrder(order); err != nil {
+		return nil, fmt.Errorf("GetCertificate: %w", err)
+	}
+
+	resp, err := c.fetchCertificate(order.Certificate)
+	if err != nil {
+		return nil, fmt.Errorf("GetCertificate: %w", err)
+	}
+	return resp.RespBody, nil
+}
+
+// GetCertificateChains fetches the default PEM certificate chain for the given
+// Order from the ACME server as well as every alternate chain the server offers
+// with a Link rel="alternate" header. The default chain is always the first
+// chain returned. The Order must have a status of "valid" and a non-empty
+// Certificate URL.
+//
+// For more information on alternate certificate chains see
+// https://tools.ietf.org/html/rfc8555#section-7.4.2
+func (c *Client) GetCertificateChains(order *resources.Order) ([]*CertificateChain, error) {
+	if err := checkCertificateOrder(order); err != nil {
+		return nil, fmt.Errorf("GetCertificateChains: %w", err)
+	}
+
+	resp, err := c.fetchCertificate(order.Certificate)
+	if err != nil {
+		return nil, fmt.Errorf("GetCertificateChains: %w", err)
+	}
+	defaultChain, err := newCertificateChain(order.Certificate, resp.RespBody)
+	if err != nil {
+		return nil, fmt.Errorf("GetCertificateChains: %w", err)
+	}
+
+	chains := []*CertificateChain{defaultChain}
+	seen := map[string]bool{order.Certificate: true}
+	for _, altURL := range linkURLs(resp.Response, "alternate") {
+		if seen[altURL] {
+			continue
+		}
+		seen[altURL] = true
+
+		altResp, err := c.fetchCertificate(altURL)
+		if err != nil {
+			return nil, fmt.Errorf("GetCertificateChains: alternate chain: %w", err)
+		}
+		altChain, err := newCertificateChain(altURL, altResp.RespBody)
+		if err != nil {
+			return nil, fmt.Errorf("GetCertificateChains: alternate chain: %w", err)
+		}
+		chains = append(chains, altChain)
+	}
+	log.Printf("Fetched %d certificate chains for order %q\n", len(chains), order.ID)
+	return chains, nil
+}
+
+// checkCertificateOrder returns an error if the given Order is nil or doesn't
+// have a certificate that can be fetched.
+func checkCertificateOrder(order *resources.Order) error {
+	if order == nil {
+		return errors.New("order must not be nil")
+	}
+	if order.Status != "valid" {
+		return fmt.Errorf("order %q is status %q, not \"valid\"", order.ID, order.Status)
+	}
+	if order.Certificate == "" {
+		return fmt.Errorf("order %q has no Certificate URL", order.ID)
+	}
+	return nil
+}
+
+// fetchCertificate fetches the given certificate URL, using POST-as-GET if the
+// Client is configured to do so. An error is returned if the request fails or
+// the server doesn't respond with a HTTP 200 status code.
+func (c *Client) fetchCertificate(certURL string) (*net.NetResponse, error) {
+	var resp *net.NetResponse
+	var err error
+	if c.PostAsGet {
+		resp, err = c.PostAsGetURL(certURL)
+	} else {
+		resp, err = c.GetURL(certURL)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckResponse(resp, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// newCertificateChain parses the given PEM bytes into a CertificateChain. An
+// error is returned if the PEM contains no certificates or if a certificate
+// can't be parsed.
+func newCertificateChain(url string, pemBytes []byte) (*CertificateChain, error) {
+	certs, err := ParseCertificates(pemBytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing certificates from %q: %w", url, err)
+	}
+	return &CertificateChain{
+		URL:   url,
+		PEM:   pemBytes,
+		Certs: certs,
+	}, nil
+}
+
+// ParseCertificates parses every PEM "CERTIFICATE" block in the given bytes.
+// Other PEM blocks are ignored. An error is returned if there are no
+// certificates or if a certificate can't be parsed.
+func ParseCertificates(pemBytes []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	rest := pemBytes
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no PEM certificates found")
+	}
+	return certs, nil
+}
+
+// ReadCertificates parses every PEM "CERTIFICATE" block in the file at the
+// given path like ParseCertificates.
+func ReadCertificates(path string) ([]*x509.Certificate, error) {
+	pemBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCertificates(pemBytes)
+}
@@ -0,0 +1,162 @@
+package client
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cpu/acmeshell/acme/resources"
+)
+
+// sctListOID is the OID of the X.509 extension holding the Signed Certificate
+// Timestamps embedded in a certificate.
+//
+// See https://tools.ietf.org/html/rfc6962#section-3.3
+var sctListOID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}
+
+// SignedCertificateTimestamp is a Certificate Transparency log's promise to
+// include a certificate in the log, as embedded in the certificate.
+//
+// See https://tools.ietf.org/html/rfc6962#section-3.2
+type SignedCertificateTimestamp struct {
+	// The SCT version. Zero for v1 SCTs.
+	Version uint8
+	// The SHA-256 hash of the log's public key.
+	LogID []byte
+	// The time the log issued the SCT.
+	Timestamp time.Time
+}
+
+// EmbeddedSCTs returns the Signed Certificate Timestamps embedded in the given
+// certificate. An empty slice is returned if the certificate has no SCT list
+// extension. An error is returned if the extension can't be parsed.
+func EmbeddedSCTs(cert *x509.Certificate) ([]SignedCertificateTimestamp, error) {
+	var sctList []byte
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(sctListOID) {
+			continue
+		}
+		if _, err := asn1.Unmarshal(ext.Value, &sctList); err != nil {
+			return nil, fmt.Errorf("error unmarshaling SCT list extension: %w", err)
+		}
+		break
+	}
+	if sctList == nil {
+		return nil, nil
+	}
+
+	list, rest, err := readUint16Prefixed(sctList)
+	if err != nil || len(rest) != 0 {
+		return nil, errors.New("malformed SCT list")
+	}
+	var scts []SignedCertificateTimestamp
+	for len(list) > 0 {
+		var sctBytes []byte
+		sctBytes, list, err = readUint16Prefixed(list)
+		if err != nil {
+			return nil, errors.New("malformed SCT list entry")
+		}
+		// version (1 byte), log ID (32 bytes) and timestamp (8 bytes) are
+		// followed by the extensions and signature, which aren't needed.
+		if len(sctBytes) < 1+32+8 {
+			return nil, errors.New("SCT is too short")
+		}
+		millis := binary.BigEndian.Uint64(sctBytes[33:41])
+		scts = append(scts, SignedCertificateTimestamp{
+			Version:   sctBytes[0],
+			LogID:     sctBytes[1:33],
+			Timestamp: time.UnixMilli(int64(millis)).UTC(),
+		})
+	}
+	return scts, nil
+}
+
+// readUint16Prefixed reads a TLS style opaque vector with a two byte length
+// prefix from the given bytes. The vector contents and the remaining bytes are
+// returned.
+func readUint16Prefixed(data []byte) ([]byte, []byte, error) {
+	if len(data) < 2 {
+		return nil, nil, errors.New("missing length prefix")
+	}
+	length := int(binary.BigEndian.Uint16(data))
+	if len(data) < 2+length {
+		return nil, nil, errors.New("length prefix exceeds data")
+	}
+	return data[2 : 2+length], data[2+length:], nil
+}
+
+// CertificateKeyID returns the ID of the client Keys entry that has the same
+// public key as the given certificate and true. If none of the client's keys
+// match an empty string and false are returned. If more than one key matches
+// the first ID in sorted order is returned.
+func (c *Client) CertificateKeyID(cert *x509.Certificate) (string, bool) {
+	ids := make([]string, 0, len(c.Keys))
+	for id := range c.Keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if PublicKeyMatches(cert, c.Keys[id]) {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// PublicKeyMatches returns true if the given signer's public key is the public
+// key of the certificate.
+func PublicKeyMatches(cert *x509.Certificate, signer crypto.Signer) bool {
+	if cert == nil || signer == nil {
+		return false
+	}
+	pub, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	return ok && pub.Equal(cert.PublicKey)
+}
+
+// CheckOrderCertificate compares the subject alternative names of the given
+// certificate with the identifiers of the given order. An error is returned for
+// each order identifier that is missing from the certificate and for each
+// certificate SAN that isn't an order identifier. A nil slice is returned if the
+// certificate matches the order.
+func CheckOrderCertificate(order *resources.Order, cert *x509.Certificate) []error {
+	var problems []error
+	var sans []string
+	sans = append(sans, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+
+	for _, ident := range order.Identifiers {
+		found := false
+		for _, san := range sans {
+			if ident.Matches(san) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			problems = append(problems, fmt.Errorf(
+				"order identifier %s %q is missing from the certificate SANs", ident.Type, ident.Value))
+		}
+	}
+
+	for _, san := range sans {
+		found := false
+		for _, ident := range order.Identifiers {
+			if ident.Matches(san) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			problems = append(problems, fmt.Errorf(
+				"certificate SAN %q is not an order identifier", san))
+		}
+	}
+	return problems
+}
@@ -8,6 +8,7 @@ import (
 	"net/mail"
 	"net/url"
 	"strings"
+	"sync"
 
 	resources "github.com/cpu/acmeshell/acme/resources"
 	"github.com/cpu/acmeshell/cmd"
@@ -63,15 +64,32 @@ type Client struct {
 	Output OutputOptions
 	// Use POST-as-GET requests instead of GET
 	PostAsGet bool
+	// Retry signed POST requests once with a fresh nonce when the server rejects
+	// them with a badNonce problem.
+	RetryBadNonce bool
+	// An optional external account binding to include when creating accounts
+	// with CreateAccount.
+	ExternalAccountBinding *ExternalAccountBinding
+	// An optional file path to the PEM encoded CA certificate(s) used as trust
+	// roots for HTTPS requests to the ACME server.
+	CACert string
 	// the net object is used to make HTTP GET/POST/HEAD requests to the ACME
 	// server.
 	net *acmenet.ACMENet
 	// directory is an in-memory representation of the ACME server's directory
 	// object.
-	directory map[string]any
-	// nonce is the value of the last-seen ReplayNonce header from the ACME
-	// server's HTTP responses. It will be used for the next signing operation.
-	nonce string
+	directory *resources.Directory
+	// nonces is a pool of unused nonces captured from the Replay-Nonce header of
+	// the ACME server's HTTP responses. They are used for signing operations
+	// before fetching a fresh nonce from the newNonce endpoint.
+	nonces []string
+	// lastNonce is the most recent nonce added to the nonces pool.
+	lastNonce string
+	// nonceMu protects the nonces pool and lastNonce.
+	nonceMu sync.Mutex
+	// renewalInfo caches ACME Renewal Information responses by ARI certificate
+	// ID so that the server's Retry-After guidance can be honoured.
+	renewalInfo map[string]*resources.RenewalInfo
 }
 
 // OutputOptions holds runtime output settings for a client.
@@ -121,6 +139,14 @@ type OutputOptions struct {
 // populated NewClient will not auto-register an account (even when AutoRegister
 // is true) and will instead load the Account serialized in the provided
 // filepath. It will be the ActiveAccount once loaded.
+//
+// The EABKeyID and EABKey fields are strings expected to contain an external
+// account binding key identifier and base64url encoded MAC key provided by the
+// ACME server operator, or to both be empty. If populated they are used to bind
+// accounts created with CreateAccount (including an auto-registered account) to
+// the external account. This is required for ACME servers that have
+// "externalAccountRequired" set in their directory meta object. See
+// https://tools.ietf.org/html/rfc8555#section-7.3.4
 type ClientConfig struct {
 	// A fully qualified URL for the ACME server's directory resource. Must
 	// include an HTTP/HTTPS protocol prefix.
@@ -147,8 +173,25 @@ type ClientConfig struct {
 	// is suggested when interacting with legacy pre RFC 8555 ACME servers. It
 	// will cause most requests to fail with modern RFC 8555 compatible servers.
 	POSTAsGET bool
+	// If RetryBadNonce is true then signed POST requests that are rejected by
+	// the ACME server with a badNonce problem are signed again with a fresh
+	// nonce and retried once.
+	RetryBadNonce bool
 	// Initial OutputOptions settings
 	InitialOutput OutputOptions
+	// An optional external account binding key identifier. Must be provided
+	// with EABKey.
+	EABKeyID string
+	// An optional base64url encoded external account binding MAC key. Must be
+	// provided with EABKeyID.
+	EABKey string
+	// An optional external account binding MAC algorithm. One of "HS256",
+	// "HS384" or "HS512". Defaults to "HS256".
+	EABAlgorithm string
+	// An optional recorder for the client's HTTP requests and responses. If
+	// provided every request made by the client, including nonce HEAD requests,
+	// is recorded while the recorder is recording.
+	HAR *acmenet.HARRecorder
 }
 
 // normalize validates a ClientConfig.
@@ -157,6 +200,9 @@ func (conf *ClientConfig) normalize() error {
 	conf.DirectoryURL = strings.TrimSpace(conf.DirectoryURL)
 	conf.ContactEmail = strings.TrimSpace(conf.ContactEmail)
 	conf.AccountPath = strings.TrimSpace(conf.AccountPath)
+	conf.EABKeyID = strings.TrimSpace(conf.EABKeyID)
+	conf.EABKey = strings.TrimSpace(conf.EABKey)
+	conf.EABAlgorithm = strings.TrimSpace(conf.EABAlgorithm)
 
 	if conf.DirectoryURL == "" {
 		return fmt.Errorf("DirectoryURL must not be empty")
@@ -174,9 +220,28 @@ func (conf *ClientConfig) normalize() error {
 		conf.ContactEmail = addr.Address
 	}
 
+	if conf.EABKeyID != "" || conf.EABKey != "" {
+		if _, _, err := conf.externalAccountBinding().normalize(); err != nil {
+			return fmt.Errorf("EABKeyID/EABKey invalid: %s", err.Error())
+		}
+	}
+
 	return nil
 }
 
+// externalAccountBinding returns an ExternalAccountBinding built from the
+// ClientConfig's EAB fields, or nil if there is no EABKeyID configured.
+func (conf *ClientConfig) externalAccountBinding() *ExternalAccountBinding {
+	if conf.EABKeyID == "" && conf.EABKey == "" {
+		return nil
+	}
+	return &ExternalAccountBinding{
+		KeyID:     conf.EABKeyID,
+		MACKey:    conf.EABKey,
+		Algorithm: conf.EABAlgorithm,
+	}
+}
+
 // NewClient creates a Client instance from the given ClientConfig. If the
 // config is not valid or if another error occurs it will be returned along with
 // a nil Client.
@@ -191,6 +256,9 @@ func NewClient(config ClientConfig) (*Client, error) {
 	// Create the ACME net client
 	net, err := acmenet.New(config.CACert)
 	cmd.FailOnError(err, "Unable to create ACME net client")
+	if config.HAR != nil {
+		net.RecordTo(config.HAR)
+	}
 
 	// NOTE(@cpu): Its safe to throw away the returned err here because we check
 	// that `url.Parse` will succeed in `config.normalize()` above.
@@ -198,15 +266,22 @@ func NewClient(config ClientConfig) (*Client, error) {
 
 	// Create a base client
 	client := &Client{
-		DirectoryURL: dirURL,
-		PostAsGet:    config.POSTAsGET,
-		Keys:         map[string]crypto.Signer{},
-		Output:       config.InitialOutput,
-		net:          net,
+		DirectoryURL:           dirURL,
+		PostAsGet:              config.POSTAsGET,
+		RetryBadNonce:          config.RetryBadNonce,
+		Keys:                   map[string]crypto.Signer{},
+		Output:                 config.InitialOutput,
+		net:                    net,
+		renewalInfo:            map[string]*resources.RenewalInfo{},
+		ExternalAccountBinding: config.externalAccountBinding(),
+		CACert:                 config.CACert,
 	}
 	if client.PostAsGet {
 		log.Printf("Using POST-as-GET requests\n")
 	}
+	if eab := client.ExternalAccountBinding; eab != nil {
+		log.Printf("Using external account binding key ID %q\n", eab.KeyID)
+	}
 
 	// If requested, try to load an existing account from disk
 	if config.AccountPath != "" {
@@ -282,7 +357,7 @@ func NewClient(config ClientConfig) (*Client, error) {
 		}
 	}
 
-	if client.nonce == "" {
+	if !client.hasNonce() {
 		if err := client.RefreshNonce(); err != nil {
 			return nil, err
 		}
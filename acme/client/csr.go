@@ -8,6 +8,7 @@ import (
 	"encoding/base64"
 	"encoding/pem"
 	"fmt"
+	"net"
 	"strings"
 
 	"github.com/cpu/acmeshell/acme/keys"
@@ -20,24 +21,35 @@ type PEMCSR string
 type B64CSR string
 
 // CSR produces a CertificateSigningRequest for the provided commonName and SAN
-// names. The keyID will be used to look up a client Keys entry to sign the CSR.
-// The CSR will use the public component of this key as the CSR public key. If
-// no commonName is provided the first of the names will be used. CSR returns
-// the PEM encoding of the CSR as well as the Base64URL encoding of the CSR.
+// names. Names that are IP addresses are included as IP address SANs, all other
+// names are included as DNS name SANs. The keyID will be used to look up
+// a client Keys entry to sign the CSR. The CSR will use the public component of
+// this key as the CSR public key. If no commonName is provided the first of the
+// DNS names (if any) will be used. CSR returns the PEM encoding of the CSR as
+// well as the Base64URL encoding of the CSR.
 func (c *Client) CSR(commonName string, names []string, keyID string) (B64CSR, PEMCSR, error) {
+	return c.CSRWithKeySpec(commonName, names, keyID, "ecdsa")
+}
+
+// CSRWithKeySpec produces a CertificateSigningRequest in the same manner as CSR.
+// If no keyID is provided a new random key is generated using the given key
+// specification (see keys.KeySpecs) instead of a P-256 ECDSA key.
+func (c *Client) CSRWithKeySpec(commonName string, names []string, keyID string, keySpec string) (B64CSR, PEMCSR, error) {
 	if len(names) == 0 {
 		return B64CSR(""), PEMCSR(""), fmt.Errorf("no names specified")
 	}
 
-	if commonName == "" {
-		commonName = names[0]
+	dnsNames, ipAddresses := SANs(names)
+	if commonName == "" && len(dnsNames) > 0 {
+		commonName = dnsNames[0]
 	}
 
 	template := x509.CertificateRequest{
 		Subject: pkix.Name{
 			CommonName: commonName,
 		},
-		DNSNames: names,
+		DNSNames:    dnsNames,
+		IPAddresses: ipAddresses,
 	}
 
 	var privateKey crypto.Signer
@@ -50,7 +62,11 @@ func (c *Client) CSR(commonName string, names []string, keyID string) (B64CSR, P
 		}
 	} else {
 		// save a new random key for the names
-		privateKey, _ = keys.NewSigner("ecdsa")
+		randKey, err := keys.NewSigner(keySpec)
+		if err != nil {
+			return B64CSR(""), PEMCSR(""), err
+		}
+		privateKey = randKey
 		c.Keys[strings.Join(names, ",")] = privateKey
 	}
 
@@ -67,3 +83,24 @@ func (c *Client) CSR(commonName string, names []string, keyID string) (B64CSR, P
 		PEMCSR(pemBytes),
 		nil
 }
+
+// SANs splits the given names into DNS names and IP addresses for use as the
+// subject alternative names of a CSR.
+//
+// See https://tools.ietf.org/html/rfc8738#section-4
+func SANs(names []string) ([]string, []net.IP) {
+	var dnsNames []string
+	var ipAddresses []net.IP
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if ip := net.ParseIP(name); ip != nil {
+			ipAddresses = append(ipAddresses, ip)
+		} else {
+			dnsNames = append(dnsNames, name)
+		}
+	}
+	return dnsNames, ipAddresses
+}
@@ -3,9 +3,11 @@ package client
 import (
 	"encoding/json"
 	"log"
+
+	"github.com/cpu/acmeshell/acme/resources"
 )
 
-func (c *Client) getDirectory() (map[string]any, error) {
+func (c *Client) getDirectory() (*resources.Directory, error) {
 	url := c.DirectoryURL.String()
 
 	resp, err := c.net.GetURL(url)
@@ -13,20 +15,21 @@ func (c *Client) getDirectory() (map[string]any, error) {
 		return nil, err
 	}
 
-	var directory map[string]any
+	var directory resources.Directory
 	err = json.Unmarshal(resp.RespBody, &directory)
 	if err != nil {
 		return nil, err
 	}
 
-	return directory, nil
+	return &directory, nil
 }
 
 // Directory fetches the ACME Directory resource from the ACME server and
-// returns it deserialized as a map.
+// returns it deserialized as a resources.Directory. The directory is cached
+// after the first fetch, use UpdateDirectory to refresh it.
 //
 // See https://tools.ietf.org/html/rfc8555#section-7.1.1
-func (c *Client) Directory() (map[string]any, error) {
+func (c *Client) Directory() (*resources.Directory, error) {
 	if c.directory == nil {
 		if err := c.UpdateDirectory(); err != nil {
 			return nil, err
@@ -62,16 +65,38 @@ func (c *Client) GetEndpointURL(name string) (string, bool) {
 	if err != nil {
 		return "", false
 	}
-	rawURL, ok := dir[name]
-	if !ok {
-		return "", false
+	return dir.Endpoint(name)
+}
+
+// externalAccountRequired returns true if the ACME server's directory meta
+// object has an "externalAccountRequired" field with a value of true.
+//
+// See https://tools.ietf.org/html/rfc8555#section-9.7.6
+func (c *Client) externalAccountRequired() bool {
+	dir, err := c.Directory()
+	if err != nil {
+		return false
 	}
-	switch v := rawURL.(type) {
-	case string:
-		if v == "" {
-			return "", false
-		}
-		return v, true
+	return dir.Meta != nil && dir.Meta.ExternalAccountRequired
+}
+
+// Profiles returns the certificate profiles advertised in the ACME server's
+// directory meta "profiles" object. The returned map is keyed by profile name
+// and has the server's description of each profile as its value. If the server
+// does not advertise any profiles an empty map is returned.
+//
+// See https://datatracker.ietf.org/doc/draft-ietf-acme-profiles/
+func (c *Client) Profiles() (map[string]string, error) {
+	dir, err := c.Directory()
+	if err != nil {
+		return nil, err
+	}
+	profiles := make(map[string]string)
+	if dir.Meta == nil {
+		return profiles, nil
+	}
+	for name, desc := range dir.Meta.Profiles {
+		profiles[name] = desc
 	}
-	return "", false
+	return profiles, nil
 }
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	jose "github.com/go-jose/go-jose/v4"
+)
+
+// ExternalAccountBinding holds the information required to bind a new ACME
+// account to an account held by the ACME server operator in another system.
+// The binding is communicated by including a JWS MAC'd with the MACKey in the
+// newAccount request.
+//
+// For information about external account binding see
+// https://tools.ietf.org/html/rfc8555#section-7.3.4
+type ExternalAccountBinding struct {
+	// The key identifier provided by the ACME server operator.
+	KeyID string
+	// The base64url encoded MAC key provided by the ACME server operator.
+	MACKey string
+	// The HMAC algorithm to use. One of "HS256", "HS384" or "HS512". If empty
+	// "HS256" is used.
+	Algorithm string
+}
+
+// eabAlgorithms are the MAC algorithms that can be used for an external account
+// binding JWS.
+var eabAlgorithms = map[string]jose.SignatureAlgorithm{
+	"HS256": jose.HS256,
+	"HS384": jose.HS384,
+	"HS512": jose.HS512,
+}
+
+// normalize validates an ExternalAccountBinding, populating the default
+// algorithm if required, and returns the decoded MAC key bytes and the
+// algorithm to use.
+func (eab *ExternalAccountBinding) normalize() ([]byte, jose.SignatureAlgorithm, error) {
+	eab.KeyID = strings.TrimSpace(eab.KeyID)
+	eab.MACKey = strings.TrimSpace(eab.MACKey)
+	eab.Algorithm = strings.ToUpper(strings.TrimSpace(eab.Algorithm))
+
+	if eab.KeyID == "" {
+		return nil, "", fmt.Errorf("external account binding KeyID must not be empty")
+	}
+	if eab.MACKey == "" {
+		return nil, "", fmt.Errorf("external account binding MACKey must not be empty")
+	}
+	if eab.Algorithm == "" {
+		eab.Algorithm = "HS256"
+	}
+
+	alg, ok := eabAlgorithms[eab.Algorithm]
+	if !ok {
+		return nil, "", fmt.Errorf(
+			"external account binding Algorithm must be HS256, HS384 or HS512 not %q",
+			eab.Algorithm)
+	}
+
+	// Tolerate MAC keys that were given with padding.
+	macKey, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(eab.MACKey, "="))
+	if err != nil {
+		return nil, "", fmt.Errorf("external account binding MACKey is not valid base64url: %s", err)
+	}
+	if len(macKey) == 0 {
+		return nil, "", fmt.Errorf("external account binding MACKey decoded to zero bytes")
+	}
+	return macKey, alg, nil
+}
+
+// sign produces the serialized external account binding JWS for the given
+// account key and newAccount URL. The JWS payload is the account's public key
+// as a JWK and the protected header has the EAB key ID, the MAC algorithm, and
+// the newAccount URL. It does not include a nonce.
+func (eab *ExternalAccountBinding) sign(accountKey crypto.Signer, url string) ([]byte, error) {
+	macKey, alg, err := eab.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	jwk := jose.JSONWebKey{
+		Key: accountKey.Public(),
+	}
+	jwkJSON, err := json.Marshal(&jwk)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal account JWK: %s", err)
+	}
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{
+			Algorithm: alg,
+			Key:       macKey,
+		},
+		&jose.SignerOptions{
+			ExtraHeaders: map[jose.HeaderKey]any{
+				"kid": eab.KeyID,
+				"url": url,
+			},
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	signed, err := signer.Sign(jwkJSON)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(signed.FullSerialize()), nil
+}
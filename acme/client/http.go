@@ -1,9 +1,14 @@
 package client
 
 import (
+	"errors"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/cpu/acmeshell/acme"
 	"github.com/cpu/acmeshell/net"
 )
 
@@ -12,6 +17,7 @@ func (c *Client) handleRequest(req *http.Request) (*net.NetResponse, error) {
 	if err != nil {
 		return nil, err
 	}
+	c.captureNonce(resp.Response)
 	if c.Output.PrintRequests {
 		log.Printf("Request:\n%s\n", resp.ReqDump)
 	}
@@ -38,11 +44,118 @@ func (c *Client) PostURL(url string, body []byte) (*net.NetResponse, error) {
 }
 
 func (c *Client) PostAsGetURL(url string) (*net.NetResponse, error) {
-	// Sign the POST-as-GET body
-	signResult, err := c.Sign(url, []byte(""), nil)
+	// Sign and POST an empty POST-as-GET body
+	return c.PostSignedURL(url, []byte(""), nil)
+}
+
+// PostSignedURL signs the given data for the url according to the
+// SigningOptions (see Sign) and POSTs the resulting JWS to the url. If the ACME
+// server rejects the request with a badNonce problem and the Client's
+// RetryBadNonce field is true the data is signed again with a fresh nonce and
+// the request is retried once.
+//
+// See https://tools.ietf.org/html/rfc8555#section-6.5
+func (c *Client) PostSignedURL(url string, data []byte, opts *SigningOptions) (*net.NetResponse, error) {
+	signResult, err := c.Sign(url, data, opts)
 	if err != nil {
 		return nil, err
 	}
 
+	resp, err := c.PostURL(url, signResult.SerializedJWS)
+	if err != nil || !c.RetryBadNonce || !isBadNonce(resp) {
+		return resp, err
+	}
+
+	log.Printf("Server rejected nonce for request to %q. Retrying with a new nonce\n", url)
+	signResult, err = c.Sign(url, data, opts)
+	if err != nil {
+		return nil, err
+	}
 	return c.PostURL(url, signResult.SerializedJWS)
 }
+
+// isBadNonce returns true if the given response has a HTTP 400 status code and
+// a problem document body with the ACME badNonce problem type.
+func isBadNonce(resp *net.NetResponse) bool {
+	var probErr *ProblemError
+	if err := CheckResponse(resp); !errors.As(err, &probErr) {
+		return false
+	}
+	return probErr.StatusCode == http.StatusBadRequest &&
+		probErr.Type == acme.BAD_NONCE_PROBLEM
+}
+
+// retryAfter parses the Retry-After header of the given response. The header
+// value may be either a number of seconds or an HTTP date. If the header is
+// absent or can not be parsed a zero duration and false are returned.
+//
+// See https://tools.ietf.org/html/rfc7231#section-7.1.3
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	val := strings.TrimSpace(resp.Header.Get(acme.RETRY_AFTER_HEADER))
+	if val == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(val); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(val); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
+
+// linkURLs returns the URLs of the Link headers in the given response that have
+// the given relation type. Relative URLs are resolved against the URL of the
+// request that produced the response.
+//
+// See https://tools.ietf.org/html/rfc8288#section-3
+func linkURLs(resp *http.Response, rel string) []string {
+	if resp == nil {
+		return nil
+	}
+	var urls []string
+	for _, header := range resp.Header.Values("Link") {
+		for _, link := range strings.Split(header, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			target = strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">")
+
+			var found bool
+			for _, param := range parts[1:] {
+				key, val, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if !ok || !strings.EqualFold(strings.TrimSpace(key), "rel") {
+					continue
+				}
+				for _, r := range strings.Fields(strings.Trim(strings.TrimSpace(val), `"`)) {
+					if strings.EqualFold(r, rel) {
+						found = true
+					}
+				}
+			}
+			if !found {
+				continue
+			}
+
+			if resp.Request != nil && resp.Request.URL != nil {
+				if ref, err := resp.Request.URL.Parse(target); err == nil {
+					target = ref.String()
+				}
+			}
+			urls = append(urls, target)
+		}
+	}
+	return urls
+}
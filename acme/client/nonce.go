@@ -1,6 +1,7 @@
 package client
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -8,22 +9,36 @@ import (
 	"github.com/cpu/acmeshell/acme"
 )
 
-// Nonce satisfies the JWS "NonceSource" interface by using a nonce stored by
-// the client from previous responses. That nonce value will be returned after
-// first getting a replacement nonce to store from the ACME server's NewNonce
-// endpoint. This ensures a constant supply of fresh nonces by always fetching
-// a replacement at the same time we use the old nonce.
+// maxPooledNonces is the maximum number of nonces captured from ACME server
+// responses that the client will hold on to. When the pool is full the oldest
+// nonce is discarded.
+const maxPooledNonces = 25
+
+// Nonce satisfies the JWS "NonceSource" interface. Nonces captured from the
+// Replay-Nonce header of previous ACME server responses are used first, most
+// recently received first. If there are no pooled nonces a fresh nonce is
+// fetched from the ACME server's NewNonce endpoint.
 func (c *Client) Nonce() (string, error) {
-	n := c.nonce
-	err := c.RefreshNonce()
-	if err != nil {
-		return n, err
+	if n, ok := c.popNonce(); ok {
+		if c.Output.PrintNonceUpdates {
+			log.Printf("Using pooled nonce %q\n", n)
+		}
+		return n, nil
+	}
+
+	if err := c.RefreshNonce(); err != nil {
+		return "", err
+	}
+
+	n, ok := c.popNonce()
+	if !ok {
+		return "", errors.New("nonce pool was empty after refreshing nonce")
 	}
 	return n, nil
 }
 
 // RefreshNonce fetches a new nonce from the ACME server's NewNonce endpoint and
-// stores it in the client's memory to be used in subsequent Nonce calls.
+// adds it to the client's nonce pool to be used in subsequent Nonce calls.
 //
 // See https://tools.ietf.org/html/rfc8555#section-7.2
 func (c *Client) RefreshNonce() error {
@@ -53,14 +68,68 @@ func (c *Client) RefreshNonce() error {
 			acme.NEW_NONCE_ENDPOINT, acme.REPLAY_NONCE_HEADER)
 	}
 
-	if nonce == c.nonce {
+	if !c.pushNonce(nonce) {
 		return fmt.Errorf("%q returned the nonce %q more than once",
-			acme.NEW_NONCE_ENDPOINT, acme.REPLAY_NONCE_HEADER)
+			acme.NEW_NONCE_ENDPOINT, nonce)
 	}
 
-	c.nonce = nonce
 	if c.Output.PrintNonceUpdates {
 		log.Printf("Updated nonce to %q", nonce)
 	}
 	return nil
 }
+
+// captureNonce adds the Replay-Nonce header value from the given HTTP response
+// (if any) to the client's nonce pool.
+func (c *Client) captureNonce(resp *http.Response) {
+	if resp == nil {
+		return
+	}
+	nonce := resp.Header.Get(acme.REPLAY_NONCE_HEADER)
+	if nonce == "" {
+		return
+	}
+	if c.pushNonce(nonce) && c.Output.PrintNonceUpdates {
+		log.Printf("Pooled nonce %q from response\n", nonce)
+	}
+}
+
+// pushNonce adds the given nonce to the nonce pool. If the nonce is the same as
+// the last nonce that was added it is not added again and false is returned.
+func (c *Client) pushNonce(nonce string) bool {
+	c.nonceMu.Lock()
+	defer c.nonceMu.Unlock()
+
+	if nonce == c.lastNonce {
+		return false
+	}
+	c.lastNonce = nonce
+
+	c.nonces = append(c.nonces, nonce)
+	if len(c.nonces) > maxPooledNonces {
+		c.nonces = c.nonces[len(c.nonces)-maxPooledNonces:]
+	}
+	return true
+}
+
+// popNonce removes and returns the most recently added nonce from the nonce
+// pool. If the pool is empty false is returned.
+func (c *Client) popNonce() (string, bool) {
+	c.nonceMu.Lock()
+	defer c.nonceMu.Unlock()
+
+	if len(c.nonces) == 0 {
+		return "", false
+	}
+	last := len(c.nonces) - 1
+	nonce := c.nonces[last]
+	c.nonces = c.nonces[:last]
+	return nonce, true
+}
+
+// hasNonce returns true if the nonce pool is not empty.
+func (c *Client) hasNonce() bool {
+	c.nonceMu.Lock()
+	defer c.nonceMu.Unlock()
+	return len(c.nonces) > 0
+}
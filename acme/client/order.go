@@ -0,0 +1,32 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// OrderTimestamp converts a requested Order notBefore or notAfter value into an
+// RFC 3339 timestamp. The value may be an RFC 3339 timestamp, or a duration
+// relative to now prefixed with "+" or "-" (e.g. "+72h" or "-1h30m"). An empty
+// value is returned unchanged.
+func OrderTimestamp(value string, now time.Time) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+
+	if strings.HasPrefix(value, "+") || strings.HasPrefix(value, "-") {
+		delta, err := time.ParseDuration(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid relative duration %q: %w", value, err)
+		}
+		return now.Add(delta).UTC().Format(time.RFC3339), nil
+	}
+
+	ts, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return "", fmt.Errorf("%q is not an RFC 3339 timestamp or relative duration", value)
+	}
+	return ts.Format(time.RFC3339), nil
+}
@@ -0,0 +1,205 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cpu/acmeshell/acme/resources"
+	"github.com/cpu/acmeshell/net"
+)
+
+const (
+	// defaultPollInterval is the delay between polls used when PollOptions has
+	// no Interval.
+	defaultPollInterval = 5 * time.Second
+	// defaultPollMaxInterval is the maximum delay between polls used when
+	// PollOptions has no MaxInterval.
+	defaultPollMaxInterval = time.Minute
+)
+
+// terminalStatuses are the statuses from which an ACME order, authorization or
+// challenge can not transition to another status.
+//
+// See https://tools.ietf.org/html/rfc8555#section-7.1.6
+var terminalStatuses = map[string]bool{
+	"valid":       true,
+	"invalid":     true,
+	"deactivated": true,
+	"expired":     true,
+	"revoked":     true,
+}
+
+// IsTerminalStatus returns true if an ACME order, authorization or challenge
+// with the given status can not transition to another status.
+func IsTerminalStatus(status string) bool {
+	return terminalStatuses[status]
+}
+
+// ErrPollTimeout is returned (wrapped) by Poll when the polled resource did not
+// reach the desired status before the PollOptions Timeout or MaxTries were
+// exhausted.
+var ErrPollTimeout = errors.New("polling timed out")
+
+// PollOptions control how Poll polls a resource.
+type PollOptions struct {
+	// The Status the resource is polled until. Required.
+	Status string
+	// The delay before the first re-poll. Defaults to 5 seconds.
+	Interval time.Duration
+	// The factor the delay is multiplied by after each poll. Values less than
+	// 1 are treated as 1 (e.g. a fixed delay).
+	Backoff float64
+	// The maximum delay the Backoff can grow the interval to. Defaults to
+	// 1 minute. It has no effect without a Backoff larger than 1. A server's
+	// Retry-After header is honoured even if it is larger than MaxInterval.
+	MaxInterval time.Duration
+	// The maximum number of polls. Zero means no limit.
+	MaxTries int
+	// The maximum total time to poll for. Zero means no limit.
+	Timeout time.Duration
+	// An optional callback invoked after each poll that didn't reach the
+	// desired status with the poll number, the current status and the delay
+	// before the next poll.
+	OnPoll func(try int, status string, delay time.Duration)
+}
+
+// PolledResource is the result of polling an ACME order, authorization or
+// challenge.
+type PolledResource struct {
+	// The URL that was polled.
+	URL string
+	// The status of the resource when polling stopped.
+	Status string
+	// The problem associated with the resource (if any). For an authorization
+	// this is the error of the first challenge with an error.
+	Error *resources.Problem
+	// The raw JSON body of the last response.
+	Body []byte
+}
+
+// Poll fetches the order, authorization or challenge at the given URL until it
+// has the status in the PollOptions. Between polls Poll sleeps for the delay
+// given by the server's Retry-After header or, if there isn't one, the
+// PollOptions Interval multiplied by Backoff for each previous poll.
+//
+// Polling stops with an error if the resource reaches a terminal status other
+// than the desired status (e.g. an order becoming "invalid" while polling for
+// "ready"), or with an error wrapping ErrPollTimeout if the Timeout or MaxTries
+// are exhausted. The last PolledResource is returned along with any error.
+//
+// See https://tools.ietf.org/html/rfc8555#section-7.5.1
+func (c *Client) Poll(url string, opts PollOptions) (*PolledResource, error) {
+	if opts.Status == "" {
+		return nil, errors.New("Poll: PollOptions Status must not be empty")
+	}
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	maxInterval := opts.MaxInterval
+	if maxInterval <= 0 {
+		maxInterval = defaultPollMaxInterval
+	}
+	backoff := opts.Backoff
+	if backoff < 1 {
+		backoff = 1
+	}
+	var deadline time.Time
+	if opts.Timeout > 0 {
+		deadline = time.Now().Add(opts.Timeout)
+	}
+
+	for try := 1; ; try++ {
+		polled, resp, err := c.pollOnce(url)
+		if err != nil {
+			return polled, fmt.Errorf("Poll: %w", err)
+		}
+		if polled.Status == opts.Status {
+			return polled, nil
+		}
+		if terminalStatuses[polled.Status] {
+			err := fmt.Errorf("Poll: %q reached terminal status %q, not %q",
+				url, polled.Status, opts.Status)
+			if polled.Error != nil {
+				err = fmt.Errorf("%w: %s", err, polled.Error)
+			}
+			return polled, err
+		}
+		if opts.MaxTries > 0 && try >= opts.MaxTries {
+			return polled, fmt.Errorf("Poll: %w after %d tries. %q is status %q",
+				ErrPollTimeout, try, url, polled.Status)
+		}
+
+		delay := interval
+		if retryDelay, ok := retryAfter(resp.Response); ok {
+			delay = retryDelay
+		}
+		if !deadline.IsZero() {
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				return polled, fmt.Errorf("Poll: %w after %s. %q is status %q",
+					ErrPollTimeout, opts.Timeout, url, polled.Status)
+			}
+			if delay > remaining {
+				delay = remaining
+			}
+		}
+
+		if opts.OnPoll != nil {
+			opts.OnPoll(try, polled.Status, delay)
+		}
+		time.Sleep(delay)
+
+		if backoff > 1 {
+			interval = time.Duration(float64(interval) * backoff)
+			if interval > maxInterval {
+				interval = maxInterval
+			}
+		}
+	}
+}
+
+// pollOnce fetches the resource at the given URL and returns its status and
+// error. The raw response is also returned so that its headers can be
+// inspected.
+func (c *Client) pollOnce(url string) (*PolledResource, *net.NetResponse, error) {
+	var resp *net.NetResponse
+	var err error
+	if c.PostAsGet {
+		resp, err = c.PostAsGetURL(url)
+	} else {
+		resp, err = c.GetURL(url)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := CheckResponse(resp, http.StatusOK); err != nil {
+		return nil, nil, err
+	}
+
+	var ob struct {
+		Status     string                `json:"status"`
+		Error      *resources.Problem    `json:"error"`
+		Challenges []resources.Challenge `json:"challenges"`
+	}
+	if err := json.Unmarshal(resp.RespBody, &ob); err != nil {
+		return nil, nil, fmt.Errorf("error unmarshaling %q: %w", url, err)
+	}
+
+	polled := &PolledResource{
+		URL:    url,
+		Status: ob.Status,
+		Error:  ob.Error,
+		Body:   resp.RespBody,
+	}
+	for _, chall := range ob.Challenges {
+		if polled.Error != nil {
+			break
+		}
+		polled.Error = chall.Error
+	}
+	return polled, resp, nil
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/cpu/acmeshell/acme/resources"
+	"github.com/cpu/acmeshell/net"
+)
+
+// problemMediaType is the media type of RFC 7807 problem documents.
+const problemMediaType = "application/problem+json"
+
+// ProblemError is an error returned when the ACME server responds to a request
+// with an unexpected HTTP status code and an RFC 7807 problem document. Callers
+// can use errors.As to access the problem document, including any subproblems.
+type ProblemError struct {
+	resources.Problem
+	// The URL of the request the problem was returned for.
+	URL string
+	// The HTTP status code of the response.
+	StatusCode int
+	// The delay the server asked for with a Retry-After header. Zero if the
+	// server did not send a Retry-After header.
+	RetryAfter time.Duration
+}
+
+// Error returns a human readable representation of the ProblemError, including
+// the full problem document and all of its subproblems.
+func (e *ProblemError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "server returned a problem for %q (status code %d): %s",
+		e.URL, e.StatusCode, e.Problem)
+	if e.RetryAfter > 0 {
+		fmt.Fprintf(&b, "\n  Retry-After: %s", e.RetryAfter)
+	}
+	return b.String()
+}
+
+// CheckResponse returns nil if the given response has one of the expected HTTP
+// status codes. Otherwise an error is returned. If the response has
+// a "application/problem+json" Content-Type and its body is a problem document
+// the error will be a *ProblemError.
+func CheckResponse(resp *net.NetResponse, expected ...int) error {
+	if resp == nil || resp.Response == nil {
+		return fmt.Errorf("response was nil")
+	}
+	respOb := resp.Response
+	for _, status := range expected {
+		if respOb.StatusCode == status {
+			return nil
+		}
+	}
+
+	var prob resources.Problem
+	mediaType, _, _ := mime.ParseMediaType(respOb.Header.Get("Content-Type"))
+	if mediaType != problemMediaType ||
+		json.Unmarshal(resp.RespBody, &prob) != nil || prob.Type == "" {
+		return fmt.Errorf("server returned status code %d, expected %v. Response body: %s",
+			respOb.StatusCode, expected, resp.RespBody)
+	}
+
+	var url string
+	if respOb.Request != nil && respOb.Request.URL != nil {
+		url = respOb.Request.URL.String()
+	}
+	probErr := &ProblemError{
+		Problem:    prob,
+		URL:        url,
+		StatusCode: respOb.StatusCode,
+	}
+	if delay, ok := retryAfter(respOb); ok {
+		probErr.RetryAfter = delay
+	}
+	return probErr
+}
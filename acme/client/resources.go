@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/cpu/acmeshell/acme"
 	"github.com/cpu/acmeshell/acme/keys"
@@ -19,7 +20,8 @@ import (
 // CreateAccount creates the given Account resource with the ACME server.
 // The Account is updated with the ID returned in the server's response's
 // Location header if the operation is successful, otherwise an error is
-// returned.
+// returned. If the Client has an ExternalAccountBinding it will be included
+// in the request.
 //
 // Important: This function always unconditionally agrees to the server's terms
 // of service (e.g. it sends "termsOfServiceAgreed:"true" in all account
@@ -29,22 +31,53 @@ import (
 // For more information on account creation see
 // https://tools.ietf.org/html/rfc8555#section-7.3
 func (c *Client) CreateAccount(acct *resources.Account) error {
-	if c.nonce == "" {
-		if err := c.RefreshNonce(); err != nil {
-			return err
-		}
-	}
+	return c.CreateAccountWithEAB(acct, c.ExternalAccountBinding)
+}
+
+// CreateAccountWithEAB creates the given Account resource with the ACME server
+// in the same manner as CreateAccount, binding it to the provided
+// ExternalAccountBinding instead of the Client's ExternalAccountBinding. If the
+// eab argument is nil no external account binding is sent.
+//
+// For more information on external account binding see
+// https://tools.ietf.org/html/rfc8555#section-7.3.4
+func (c *Client) CreateAccountWithEAB(acct *resources.Account, eab *ExternalAccountBinding) error {
 	if acct.ID != "" {
 		return fmt.Errorf(
 			"create: account already exists under ID %q", acct.ID)
 	}
 
+	newAcctURL, ok := c.GetEndpointURL(acme.NEW_ACCOUNT_ENDPOINT)
+	if !ok {
+		return fmt.Errorf(
+			"create: ACME server missing %q endpoint in directory",
+			acme.NEW_ACCOUNT_ENDPOINT)
+	}
+
+	if eab == nil && c.externalAccountRequired() {
+		return fmt.Errorf(
+			"create: ACME server requires external account binding and none was provided")
+	}
+
+	var eabJWS []byte
+	if eab != nil {
+		var err error
+		eabJWS, err = eab.sign(acct.Signer, newAcctURL)
+		if err != nil {
+			return fmt.Errorf("create: external account binding: %s", err)
+		}
+		log.Printf("Binding account to external account key ID %q (%s)\n",
+			eab.KeyID, eab.Algorithm)
+	}
+
 	newAcctReq := struct {
-		Contact   []string `json:",omitempty"`
-		ToSAgreed bool     `json:"termsOfServiceAgreed"`
+		Contact   []string        `json:",omitempty"`
+		ToSAgreed bool            `json:"termsOfServiceAgreed"`
+		EAB       json.RawMessage `json:"externalAccountBinding,omitempty"`
 	}{
 		Contact:   acct.Contact,
 		ToSAgreed: true,
+		EAB:       eabJWS,
 	}
 
 	reqBody, err := json.Marshal(&newAcctReq)
@@ -52,14 +85,9 @@ func (c *Client) CreateAccount(acct *resources.Account) error {
 		return err
 	}
 
-	newAcctURL, ok := c.GetEndpointURL(acme.NEW_ACCOUNT_ENDPOINT)
-	if !ok {
-		return fmt.Errorf(
-			"create: ACME server missing %q endpoint in directory",
-			acme.NEW_ACCOUNT_ENDPOINT)
-	}
-
-	signResult, err := c.Sign(
+	log.Printf("Sending %q request (contact: %s) to %q",
+		acme.NEW_ACCOUNT_ENDPOINT, acct.Contact, newAcctURL)
+	resp, err := c.PostSignedURL(
 		newAcctURL,
 		reqBody,
 		&SigningOptions{
@@ -70,18 +98,10 @@ func (c *Client) CreateAccount(acct *resources.Account) error {
 		return fmt.Errorf("create: %s", err)
 	}
 
-	log.Printf("Sending %q request (contact: %s) to %q",
-		acme.NEW_ACCOUNT_ENDPOINT, acct.Contact, newAcctURL)
-	resp, err := c.PostURL(newAcctURL, signResult.SerializedJWS)
-	if err != nil {
-		return err
+	if err := CheckResponse(resp, http.StatusCreated); err != nil {
+		return fmt.Errorf("create: %w", err)
 	}
-
 	respOb := resp.Response
-	if respOb.StatusCode != http.StatusCreated {
-		return fmt.Errorf("create: server returned status code %d, expected %d",
-			respOb.StatusCode, http.StatusCreated)
-	}
 
 	locHeader := respOb.Header.Get("Location")
 	if locHeader == "" {
@@ -94,6 +114,128 @@ func (c *Client) CreateAccount(acct *resources.Account) error {
 	return nil
 }
 
+// LookupAccount finds the existing Account with the ACME server that is
+// associated with the given signer's public key. The newAccount request is sent
+// with "onlyReturnExisting" set to true so that no new account is created. If
+// the operation is successful an Account with the server's Location header as
+// its ID and the given signer is returned. Otherwise a non-nil error is
+// returned.
+//
+// For more information on finding an account given a key see
+// https://tools.ietf.org/html/rfc8555#section-7.3.1
+func (c *Client) LookupAccount(signer crypto.Signer) (*resources.Account, error) {
+	if signer == nil {
+		return nil, fmt.Errorf("lookupAccount: signer must not be nil")
+	}
+
+	newAcctURL, ok := c.GetEndpointURL(acme.NEW_ACCOUNT_ENDPOINT)
+	if !ok {
+		return nil, fmt.Errorf(
+			"lookupAccount: ACME server missing %q endpoint in directory",
+			acme.NEW_ACCOUNT_ENDPOINT)
+	}
+
+	req := struct {
+		OnlyReturnExisting bool `json:"onlyReturnExisting"`
+	}{
+		OnlyReturnExisting: true,
+	}
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Looking up existing account for key with thumbprint %q\n",
+		keys.JWKThumbprint(signer))
+	resp, err := c.PostSignedURL(newAcctURL, reqBody, &SigningOptions{
+		EmbedKey: true,
+		Signer:   signer,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("lookupAccount: %w", err)
+	}
+	if err := CheckResponse(resp, http.StatusOK); err != nil {
+		return nil, fmt.Errorf("lookupAccount: %w", err)
+	}
+
+	locHeader := resp.Response.Header.Get("Location")
+	if locHeader == "" {
+		return nil, fmt.Errorf("lookupAccount: server returned response with no Location header")
+	}
+
+	var existing struct {
+		Status  string   `json:"status"`
+		Contact []string `json:"contact"`
+	}
+	if err := json.Unmarshal(resp.RespBody, &existing); err != nil {
+		return nil, fmt.Errorf("lookupAccount: error unmarshaling response: %w", err)
+	}
+
+	log.Printf("Found existing account with ID %q\n", locHeader)
+	return &resources.Account{
+		ID:      locHeader,
+		Contact: existing.Contact,
+		Status:  existing.Status,
+		Signer:  signer,
+	}, nil
+}
+
+// UpdateAccount updates the given Account resource with the ACME server. If the
+// contacts argument is not nil the Account's contacts are replaced with the
+// given contact URIs. A non-nil empty contacts slice removes all of the
+// Account's contacts. If agreeTOS is true the request also indicates agreement
+// with the server's terms of service. If the operation is successful the
+// Account's Contact and Status fields are updated from the server's response,
+// otherwise a non-nil error is returned.
+//
+// For more information on account updates see
+// https://tools.ietf.org/html/rfc8555#section-7.3.2
+func (c *Client) UpdateAccount(acct *resources.Account, contacts []string, agreeTOS bool) error {
+	if acct == nil || acct.ID == "" {
+		return fmt.Errorf("updateAccount: account is nil or has not been created")
+	}
+
+	req := struct {
+		Contact              *[]string `json:"contact,omitempty"`
+		TermsOfServiceAgreed bool      `json:"termsOfServiceAgreed,omitempty"`
+	}{
+		TermsOfServiceAgreed: agreeTOS,
+	}
+	if contacts != nil {
+		req.Contact = &contacts
+	}
+
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Sending account update request to %q\n", acct.ID)
+	resp, err := c.PostSignedURL(acct.ID, reqBody, &SigningOptions{
+		KeyID:  acct.ID,
+		Signer: acct.Signer,
+	})
+	if err != nil {
+		return fmt.Errorf("updateAccount: %w", err)
+	}
+	if err := CheckResponse(resp, http.StatusOK); err != nil {
+		return fmt.Errorf("updateAccount: %w", err)
+	}
+
+	var updated struct {
+		Status  string   `json:"status"`
+		Contact []string `json:"contact"`
+	}
+	if err := json.Unmarshal(resp.RespBody, &updated); err != nil {
+		return fmt.Errorf("updateAccount: error unmarshaling response: %w", err)
+	}
+
+	acct.Contact = updated.Contact
+	acct.Status = updated.Status
+	log.Printf("Updated account %q\n", acct.ID)
+	return nil
+}
+
 func (c *Client) Rollover(newKey crypto.Signer) error {
 	acctID := c.ActiveAccountID()
 	if c.ActiveAccountID() == "" {
@@ -131,20 +273,14 @@ func (c *Client) Rollover(newKey crypto.Signer) error {
 		return fmt.Errorf("error signing inner JWS: %v", err)
 	}
 
-	outerSignResult, err := c.Sign(targetURL, innerSignResult.SerializedJWS, nil)
-	if err != nil {
-		return fmt.Errorf("error signing outer JWS: %v", err)
-	}
-
 	log.Printf("Rolling over account %q to use new key\n", acctID)
-	resp, err := c.PostURL(targetURL, outerSignResult.SerializedJWS)
+	resp, err := c.PostSignedURL(targetURL, innerSignResult.SerializedJWS, nil)
 	if err != nil {
 		return fmt.Errorf("rollover POST request failed: %v", err)
 	}
 
-	respOb := resp.Response
-	if respOb.StatusCode != http.StatusOK {
-		return fmt.Errorf("rollover POST request failed. Status code: %d", respOb.StatusCode)
+	if err := CheckResponse(resp, http.StatusOK); err != nil {
+		return fmt.Errorf("rollover POST request failed: %w", err)
 	}
 
 	c.Keys[account.ID] = newKey
@@ -155,25 +291,42 @@ func (c *Client) Rollover(newKey crypto.Signer) error {
 
 // CreateOrder creates the given Order resource with the ACME server. If the
 // operation is successful, the order is mutated in place with the response
-// from the server's reply. Otherwise a non-nil error is returned.
+// from the server's reply. Otherwise a non-nil error is returned. If the Order
+// has a non-empty Replaces field it is sent to the server as the ARI "replaces"
+// value identifying the certificate the Order is replacing. Non-empty NotBefore,
+// NotAfter and Profile fields are also sent to the server. NotBefore and
+// NotAfter may be RFC 3339 timestamps or durations relative to the current time
+// (see OrderTimestamp).
 //
 // For more information on Order creation see "Applying for Certificate
 // Issuance" in RFC 8555:
 // https://tools.ietf.org/html/rfc8555#section-7.4
 func (c *Client) CreateOrder(order *resources.Order) error {
-	if c.nonce == "" {
-		if err := c.RefreshNonce(); err != nil {
-			return err
-		}
-	}
 	if c.ActiveAccountID() == "" {
 		return fmt.Errorf("createOrder: active account is nil or has not been created")
 	}
 
+	notBefore, err := OrderTimestamp(order.NotBefore, time.Now())
+	if err != nil {
+		return fmt.Errorf("createOrder: invalid notBefore: %w", err)
+	}
+	notAfter, err := OrderTimestamp(order.NotAfter, time.Now())
+	if err != nil {
+		return fmt.Errorf("createOrder: invalid notAfter: %w", err)
+	}
+
 	req := struct {
 		Identifiers []resources.Identifier `json:"identifiers"`
+		NotBefore   string                 `json:"notBefore,omitempty"`
+		NotAfter    string                 `json:"notAfter,omitempty"`
+		Replaces    string                 `json:"replaces,omitempty"`
+		Profile     string                 `json:"profile,omitempty"`
 	}{
 		Identifiers: order.Identifiers,
+		NotBefore:   notBefore,
+		NotAfter:    notAfter,
+		Replaces:    order.Replaces,
+		Profile:     order.Profile,
 	}
 
 	reqBody, err := json.Marshal(req)
@@ -188,22 +341,16 @@ func (c *Client) CreateOrder(order *resources.Order) error {
 			acme.NEW_ORDER_ENDPOINT)
 	}
 
-	// Sign the new order request with the active account
-	signResult, err := c.Sign(newOrderURL, reqBody, nil)
+	// Sign and POST the new order request with the active account
+	resp, err := c.PostSignedURL(newOrderURL, reqBody, nil)
 	if err != nil {
 		return fmt.Errorf("createOrder: %s", err)
 	}
 
-	resp, err := c.PostURL(newOrderURL, signResult.SerializedJWS)
-	if err != nil {
-		return err
+	if err := CheckResponse(resp, http.StatusCreated); err != nil {
+		return fmt.Errorf("createOrder: %w", err)
 	}
-
 	respOb := resp.Response
-	if respOb.StatusCode != http.StatusCreated {
-		return fmt.Errorf("createOrder: server returned status code %d, expected %d",
-			respOb.StatusCode, http.StatusCreated)
-	}
 
 	locHeader := respOb.Header.Get("Location")
 	if locHeader == "" {
@@ -248,6 +395,9 @@ func (c *Client) UpdateOrder(order *resources.Order) error {
 	if err != nil {
 		return err
 	}
+	if err := CheckResponse(resp, http.StatusOK); err != nil {
+		return fmt.Errorf("updateOrder: %w", err)
+	}
 
 	err = json.Unmarshal(resp.RespBody, &order)
 	if err != nil {
@@ -257,6 +407,62 @@ func (c *Client) UpdateOrder(order *resources.Order) error {
 	return nil
 }
 
+// CreateAuthz creates a standalone Authorization for the given identifier with
+// the ACME server's newAuthz endpoint (pre-authorization). If the operation is
+// successful the created Authorization is returned and its URL is recorded in
+// the active Account's Authzs. Otherwise a non-nil error is returned.
+//
+// For more information on pre-authorization see
+// https://tools.ietf.org/html/rfc8555#section-7.4.1
+func (c *Client) CreateAuthz(identifier resources.Identifier) (*resources.Authorization, error) {
+	if c.ActiveAccountID() == "" {
+		return nil, fmt.Errorf("createAuthz: active account is nil or has not been created")
+	}
+
+	newAuthzURL, ok := c.GetEndpointURL(acme.NEW_AUTHZ_ENDPOINT)
+	if !ok {
+		return nil, fmt.Errorf(
+			"createAuthz: ACME server missing %q endpoint in directory",
+			acme.NEW_AUTHZ_ENDPOINT)
+	}
+
+	req := struct {
+		Identifier resources.Identifier `json:"identifier"`
+	}{
+		Identifier: identifier,
+	}
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sign and POST the new authz request with the active account
+	resp, err := c.PostSignedURL(newAuthzURL, reqBody, nil)
+	if err != nil {
+		return nil, fmt.Errorf("createAuthz: %w", err)
+	}
+	if err := CheckResponse(resp, http.StatusCreated); err != nil {
+		return nil, fmt.Errorf("createAuthz: %w", err)
+	}
+
+	locHeader := resp.Response.Header.Get("Location")
+	if locHeader == "" {
+		return nil, fmt.Errorf("createAuthz: server returned response with no Location header")
+	}
+
+	authz := &resources.Authorization{}
+	if err := json.Unmarshal(resp.RespBody, authz); err != nil {
+		return nil, fmt.Errorf("createAuthz: server returned invalid JSON: %w", err)
+	}
+
+	// Store the Location header as the Authorization's ID
+	authz.ID = locHeader
+	log.Printf("Created new authz with ID %q\n", authz.ID)
+	// Save the authz for the account
+	c.ActiveAccount.Authzs = append(c.ActiveAccount.Authzs, authz.ID)
+	return authz, nil
+}
+
 // UpdateAuthz refreshes a given Authz by fetching its ID URL from the ACME
 // server. If this is successful the Authz is updated in place. Otherwise an
 // error is returned.
@@ -281,6 +487,9 @@ func (c *Client) UpdateAuthz(authz *resources.Authorization) error {
 	if err != nil {
 		return err
 	}
+	if err := CheckResponse(resp, http.StatusOK); err != nil {
+		return fmt.Errorf("UpdateAuthz: %w", err)
+	}
 
 	err = json.Unmarshal(resp.RespBody, &authz)
 	if err != nil {
@@ -311,6 +520,9 @@ func (c *Client) UpdateChallenge(chall *resources.Challenge) error {
 	if err != nil {
 		return err
 	}
+	if err := CheckResponse(resp, http.StatusOK); err != nil {
+		return fmt.Errorf("UpdateChallenge: %w", err)
+	}
 
 	err = json.Unmarshal(resp.RespBody, &chall)
 	if err != nil {
@@ -340,6 +552,112 @@ func (c *Client) OrderByIndex(index int) (*resources.Order, error) {
 	return order, nil
 }
 
+// maxOrderListPages is the maximum number of orders list pages that
+// SyncOrders will fetch by following Link rel="next" headers.
+const maxOrderListPages = 100
+
+// SyncOrders fetches the active Account's orders list from the ACME server by
+// following the "orders" URL of the Account object and any Link rel="next"
+// headers for additional pages. Order URLs the Account doesn't already know
+// about are appended to the Account's Orders, preserving the indexes of
+// existing orders. The deduplicated list of order URLs returned by the server
+// is returned if the operation is successful, otherwise a non-nil error is
+// returned.
+//
+// For more information on the orders list see
+// https://tools.ietf.org/html/rfc8555#section-7.1.2.1
+func (c *Client) SyncOrders() ([]string, error) {
+	if c.ActiveAccountID() == "" {
+		return nil, errors.New("SyncOrders: active account is nil or has not been created")
+	}
+	acct := c.ActiveAccount
+
+	// Fetch the account object to find the orders URL. Servers that don't
+	// support POST-as-GET treat a POST with an empty JSON object as an account
+	// update without changes.
+	acctBody := []byte("")
+	if !c.PostAsGet {
+		acctBody = []byte("{}")
+	}
+	resp, err := c.PostSignedURL(acct.ID, acctBody, nil)
+	if err != nil {
+		return nil, fmt.Errorf("SyncOrders: %w", err)
+	}
+	if err := CheckResponse(resp, http.StatusOK); err != nil {
+		return nil, fmt.Errorf("SyncOrders: error fetching account: %w", err)
+	}
+
+	var acctOb struct {
+		Orders string `json:"orders"`
+	}
+	if err := json.Unmarshal(resp.RespBody, &acctOb); err != nil {
+		return nil, fmt.Errorf("SyncOrders: error unmarshaling account: %w", err)
+	}
+	if acctOb.Orders == "" {
+		return nil, fmt.Errorf("SyncOrders: account %q has no orders URL", acct.ID)
+	}
+
+	var remoteOrders []string
+	seen := make(map[string]bool)
+	visited := make(map[string]bool)
+	pageURL := acctOb.Orders
+	for pageURL != "" && !visited[pageURL] {
+		if len(visited) >= maxOrderListPages {
+			return nil, fmt.Errorf(
+				"SyncOrders: orders list for %q has more than %d pages",
+				acct.ID, maxOrderListPages)
+		}
+		visited[pageURL] = true
+
+		if c.PostAsGet {
+			resp, err = c.PostAsGetURL(pageURL)
+		} else {
+			resp, err = c.GetURL(pageURL)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("SyncOrders: %w", err)
+		}
+		if err := CheckResponse(resp, http.StatusOK); err != nil {
+			return nil, fmt.Errorf("SyncOrders: error fetching orders list: %w", err)
+		}
+
+		var page struct {
+			Orders []string `json:"orders"`
+		}
+		if err := json.Unmarshal(resp.RespBody, &page); err != nil {
+			return nil, fmt.Errorf("SyncOrders: error unmarshaling orders list: %w", err)
+		}
+		for _, orderURL := range page.Orders {
+			if !seen[orderURL] {
+				seen[orderURL] = true
+				remoteOrders = append(remoteOrders, orderURL)
+			}
+		}
+
+		pageURL = ""
+		if next := linkURLs(resp.Response, "next"); len(next) > 0 {
+			pageURL = next[0]
+		}
+	}
+
+	known := make(map[string]bool, len(acct.Orders))
+	for _, orderURL := range acct.Orders {
+		known[orderURL] = true
+	}
+	var added int
+	for _, orderURL := range remoteOrders {
+		if known[orderURL] {
+			continue
+		}
+		known[orderURL] = true
+		acct.Orders = append(acct.Orders, orderURL)
+		added++
+	}
+	log.Printf("Synced %d orders for account %q (%d new)\n",
+		len(remoteOrders), acct.ID, added)
+	return remoteOrders, nil
+}
+
 func (c *Client) AuthzByIdentifier(order *resources.Order, identifier string) (*resources.Authorization, error) {
 	if order == nil {
 		return nil, errors.New("AuthzByIdentifier: Order was nil")
@@ -355,7 +673,7 @@ func (c *Client) AuthzByIdentifier(order *resources.Order, identifier string) (*
 		if err := c.UpdateAuthz(authz); err != nil {
 			return nil, err
 		}
-		if authz.Identifier.Value == identifier {
+		if authz.Identifier.Matches(identifier) {
 			return authz, nil
 		}
 	}
@@ -0,0 +1,224 @@
+package client
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// revocationReasons maps the names of the RFC 5280 CRLReason codes to their
+// values. Code 7 is unused.
+//
+// See https://tools.ietf.org/html/rfc5280#section-5.3.1
+var revocationReasons = map[string]int{
+	"unspecified":          ocsp.Unspecified,
+	"keyCompromise":        ocsp.KeyCompromise,
+	"cACompromise":         ocsp.CACompromise,
+	"affiliationChanged":   ocsp.AffiliationChanged,
+	"superseded":           ocsp.Superseded,
+	"cessationOfOperation": ocsp.CessationOfOperation,
+	"certificateHold":      ocsp.CertificateHold,
+	"removeFromCRL":        ocsp.RemoveFromCRL,
+	"privilegeWithdrawn":   ocsp.PrivilegeWithdrawn,
+	"aACompromise":         ocsp.AACompromise,
+}
+
+// RevocationReasonName returns the RFC 5280 name of the given CRLReason code,
+// or the code as a string if it isn't a known reason.
+func RevocationReasonName(code int) string {
+	for name, c := range revocationReasons {
+		if c == code {
+			return name
+		}
+	}
+	return strconv.Itoa(code)
+}
+
+// ParseRevocationReason returns the CRLReason code for the given reason. The
+// reason may be a RFC 5280 reason name (case-insensitive, e.g.
+// "keyCompromise") or a reason code number.
+func ParseRevocationReason(reason string) (int, error) {
+	reason = strings.TrimSpace(reason)
+	if code, err := strconv.Atoi(reason); err == nil {
+		return code, nil
+	}
+	for name, code := range revocationReasons {
+		if strings.EqualFold(name, reason) {
+			return code, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown revocation reason %q. Known reasons: %s",
+		reason, strings.Join(RevocationReasonNames(), ", "))
+}
+
+// RevocationReasonNames returns the RFC 5280 CRLReason names sorted by code.
+func RevocationReasonNames() []string {
+	names := make([]string, 0, len(revocationReasons))
+	for name := range revocationReasons {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return revocationReasons[names[i]] < revocationReasons[names[j]]
+	})
+	return names
+}
+
+// RevocationStatus is the revocation status of a certificate reported by an
+// OCSP responder or a CRL.
+type RevocationStatus struct {
+	// The OCSP responder or CRL URL the status was fetched from.
+	URL string `json:"url"`
+	// One of "good", "revoked" or "unknown". A CRL reports "good" for any
+	// certificate it doesn't list.
+	Status string `json:"status"`
+	// The time the certificate was revoked. Only set if Status is "revoked".
+	RevokedAt time.Time `json:"revokedAt"`
+	// The CRLReason code for the revocation. Only set if Status is "revoked".
+	Reason int `json:"reason"`
+	// The time the status was produced.
+	ThisUpdate time.Time `json:"thisUpdate"`
+	// The time a newer status will be available. May be the zero time.
+	NextUpdate time.Time `json:"nextUpdate"`
+}
+
+// CheckOCSP requests the revocation status of the given certificate from an
+// OCSP responder. If responderURL is empty the first OCSP server from the
+// certificate's AIA extension is used. The response signature is verified
+// against the issuer certificate (directly or through a delegated OCSP
+// responder certificate issued by it).
+//
+// See https://tools.ietf.org/html/rfc6960
+func (c *Client) CheckOCSP(cert, issuer *x509.Certificate, responderURL string) (*RevocationStatus, error) {
+	if responderURL == "" {
+		if len(cert.OCSPServer) == 0 {
+			return nil, errors.New("CheckOCSP: certificate has no AIA OCSP server")
+		}
+		responderURL = cert.OCSPServer[0]
+	}
+
+	ocspReq, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return nil, fmt.Errorf("CheckOCSP: error creating request: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, responderURL, bytes.NewReader(ocspReq))
+	if err != nil {
+		return nil, fmt.Errorf("CheckOCSP: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/ocsp-request")
+	req.Header.Set("Accept", "application/ocsp-response")
+
+	resp, err := c.handleRequest(req)
+	if err != nil {
+		return nil, fmt.Errorf("CheckOCSP: %w", err)
+	}
+	if resp.Response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("CheckOCSP: responder %q returned HTTP status %d",
+			responderURL, resp.Response.StatusCode)
+	}
+
+	ocspResp, err := ocsp.ParseResponseForCert(resp.RespBody, cert, issuer)
+	if err != nil {
+		return nil, fmt.Errorf("CheckOCSP: invalid response from %q: %w", responderURL, err)
+	}
+
+	status := &RevocationStatus{
+		URL:        responderURL,
+		ThisUpdate: ocspResp.ThisUpdate,
+		NextUpdate: ocspResp.NextUpdate,
+	}
+	switch ocspResp.Status {
+	case ocsp.Good:
+		status.Status = "good"
+	case ocsp.Revoked:
+		status.Status = "revoked"
+		status.RevokedAt = ocspResp.RevokedAt
+		status.Reason = ocspResp.RevocationReason
+	default:
+		status.Status = "unknown"
+	}
+	return status, nil
+}
+
+// CheckCRL fetches a CRL and looks up the revocation status of the given
+// certificate's serial number in it. If crlURL is empty the first CRL
+// distribution point from the certificate is used. The CRL signature is
+// verified against the issuer certificate.
+//
+// See https://tools.ietf.org/html/rfc5280#section-5
+func (c *Client) CheckCRL(cert, issuer *x509.Certificate, crlURL string) (*RevocationStatus, error) {
+	if crlURL == "" {
+		if len(cert.CRLDistributionPoints) == 0 {
+			return nil, errors.New("CheckCRL: certificate has no CRL distribution points")
+		}
+		crlURL = cert.CRLDistributionPoints[0]
+	}
+
+	resp, err := c.GetURL(crlURL)
+	if err != nil {
+		return nil, fmt.Errorf("CheckCRL: %w", err)
+	}
+	if resp.Response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("CheckCRL: %q returned HTTP status %d",
+			crlURL, resp.Response.StatusCode)
+	}
+
+	der := resp.RespBody
+	if block, _ := pem.Decode(der); block != nil && block.Type == "X509 CRL" {
+		der = block.Bytes
+	}
+	crl, err := x509.ParseRevocationList(der)
+	if err != nil {
+		return nil, fmt.Errorf("CheckCRL: error parsing CRL from %q: %w", crlURL, err)
+	}
+	if err := crl.CheckSignatureFrom(issuer); err != nil {
+		return nil, fmt.Errorf("CheckCRL: CRL from %q has invalid signature: %w", crlURL, err)
+	}
+
+	status := &RevocationStatus{
+		URL:        crlURL,
+		Status:     "good",
+		ThisUpdate: crl.ThisUpdate,
+		NextUpdate: crl.NextUpdate,
+	}
+	for _, entry := range crl.RevokedCertificateEntries {
+		if entry.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+			status.Status = "revoked"
+			status.RevokedAt = entry.RevocationTime
+			status.Reason = entry.ReasonCode
+			break
+		}
+	}
+	return status, nil
+}
+
+// FetchIssuer fetches the issuer of the given certificate from the
+// certificate's AIA caIssuers URLs. The first issuer certificate that verifies
+// the certificate's signature is returned.
+func (c *Client) FetchIssuer(cert *x509.Certificate) (*x509.Certificate, error) {
+	if len(cert.IssuingCertificateURL) == 0 {
+		return nil, errors.New("FetchIssuer: certificate has no AIA caIssuers URL")
+	}
+	var lastErr error
+	for _, aiaURL := range cert.IssuingCertificateURL {
+		issuer, err := c.fetchAIACertificate(aiaURL)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if err := cert.CheckSignatureFrom(issuer); err != nil {
+			lastErr = fmt.Errorf("certificate from %q is not the issuer: %w", aiaURL, err)
+			continue
+		}
+		return issuer, nil
+	}
+	return nil, fmt.Errorf("FetchIssuer: %w", lastErr)
+}
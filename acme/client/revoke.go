@@ -0,0 +1,182 @@
+package client
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/cpu/acmeshell/acme/resources"
+	"github.com/cpu/acmeshell/net"
+	"golang.org/x/crypto/ocsp"
+)
+
+// subscriberRevocationReasons are the CRLReason codes an ACME CA is expected to
+// accept in a revocation request. Other codes (cACompromise, certificateHold,
+// removeFromCRL, privilegeWithdrawn and aACompromise) are reserved for use by
+// the CA itself.
+//
+// See https://tools.ietf.org/html/rfc8555#section-7.6 and section 4.9.1.1 of
+// the CA/Browser Forum Baseline Requirements.
+var subscriberRevocationReasons = map[int]bool{
+	ocsp.Unspecified:          true,
+	ocsp.KeyCompromise:        true,
+	ocsp.AffiliationChanged:   true,
+	ocsp.Superseded:           true,
+	ocsp.CessationOfOperation: true,
+}
+
+// RevocationReasonWarning returns a warning describing why an ACME CA is
+// expected to reject a revocation request with the given CRLReason code, or an
+// empty string if the reason is expected to be accepted.
+func RevocationReasonWarning(reason int) string {
+	if subscriberRevocationReasons[reason] {
+		return ""
+	}
+	if _, known := revocationReasons[RevocationReasonName(reason)]; !known {
+		return fmt.Sprintf("reason code %d is not a RFC 5280 CRLReason. "+
+			"The CA is expected to reject it with a badRevocationReason error", reason)
+	}
+	return fmt.Sprintf("reason %q is reserved for the CA. "+
+		"The CA is expected to reject it with a badRevocationReason error",
+		RevocationReasonName(reason))
+}
+
+// RevokeCertificate asks the ACME server to revoke the given certificate with
+// the given CRLReason code. The revocation request is signed according to the
+// SigningOptions. Nil SigningOptions sign the request with the active account,
+// which must either be the account that issued the certificate or an account
+// holding valid authorizations for every identifier in the certificate. To
+// sign the request with the certificate's key use SigningOptions with EmbedKey
+// and the certificate's private key as the Signer.
+//
+// See https://tools.ietf.org/html/rfc8555#section-7.6
+func (c *Client) RevokeCertificate(cert *x509.Certificate, reason int, opts *SigningOptions) error {
+	revokeURL, ok := c.GetEndpointURL("revokeCert")
+	if !ok {
+		return fmt.Errorf("RevokeCertificate: ACME server missing %q endpoint in directory",
+			"revokeCert")
+	}
+
+	revokeRequest := struct {
+		Certificate string `json:"certificate"`
+		Reason      int    `json:"reason"`
+	}{
+		Certificate: base64.RawURLEncoding.EncodeToString(cert.Raw),
+		Reason:      reason,
+	}
+	revokeRequestJSON, err := json.Marshal(&revokeRequest)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.PostSignedURL(revokeURL, revokeRequestJSON, opts)
+	if err != nil {
+		return fmt.Errorf("RevokeCertificate: %w", err)
+	}
+	if err := CheckResponse(resp, http.StatusOK); err != nil {
+		return fmt.Errorf("RevokeCertificate: %w", err)
+	}
+	log.Printf("Revoked certificate with serial %x\n", cert.SerialNumber)
+	return nil
+}
+
+// AuthorizedIdentifiers returns the identifiers the given account holds valid,
+// unexpired authorizations for. The authorizations of the account's orders and
+// its standalone authorizations are fetched from the ACME server with
+// POST-as-GET requests signed by the account (unless the Client's PostAsGet is
+// false). Identifiers of wildcard authorizations are returned with a "*."
+// prefix.
+func (c *Client) AuthorizedIdentifiers(acct *resources.Account) ([]resources.Identifier, error) {
+	if acct == nil || acct.ID == "" {
+		return nil, fmt.Errorf("AuthorizedIdentifiers: account is nil or has not been created")
+	}
+	signOpts := &SigningOptions{
+		KeyID:  acct.ID,
+		Signer: acct.Signer,
+	}
+
+	authzURLs := append([]string{}, acct.Authzs...)
+	for _, orderURL := range acct.Orders {
+		var order resources.Order
+		if err := c.fetchResource(orderURL, signOpts, &order); err != nil {
+			return nil, fmt.Errorf("AuthorizedIdentifiers: %w", err)
+		}
+		authzURLs = append(authzURLs, order.Authorizations...)
+	}
+
+	var identifiers []resources.Identifier
+	seen := map[string]bool{}
+	now := time.Now()
+	for _, authzURL := range authzURLs {
+		if seen[authzURL] {
+			continue
+		}
+		seen[authzURL] = true
+
+		var authz resources.Authorization
+		if err := c.fetchResource(authzURL, signOpts, &authz); err != nil {
+			return nil, fmt.Errorf("AuthorizedIdentifiers: %w", err)
+		}
+		if authz.Status != "valid" {
+			continue
+		}
+		if expires, err := time.Parse(time.RFC3339, authz.Expires); err == nil && expires.Before(now) {
+			continue
+		}
+		ident := authz.Identifier
+		if authz.Wildcard {
+			ident.Value = "*." + ident.Value
+		}
+		identifiers = append(identifiers, ident)
+	}
+	return identifiers, nil
+}
+
+// fetchResource fetches the JSON resource at the given URL and unmarshals it
+// into the given value. POST-as-GET requests are signed according to the
+// SigningOptions.
+func (c *Client) fetchResource(url string, opts *SigningOptions, ob any) error {
+	var resp *net.NetResponse
+	var err error
+	if c.PostAsGet {
+		resp, err = c.PostSignedURL(url, []byte(""), opts)
+	} else {
+		resp, err = c.GetURL(url)
+	}
+	if err != nil {
+		return err
+	}
+	if err := CheckResponse(resp, http.StatusOK); err != nil {
+		return err
+	}
+	return json.Unmarshal(resp.RespBody, ob)
+}
+
+// UnauthorizedNames returns the DNS and IP address SANs of the given
+// certificate that don't match any of the given identifiers.
+func UnauthorizedNames(cert *x509.Certificate, identifiers []resources.Identifier) []string {
+	var names []string
+	names = append(names, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+
+	var unauthorized []string
+	for _, name := range names {
+		authorized := false
+		for _, ident := range identifiers {
+			if ident.Matches(name) {
+				authorized = true
+				break
+			}
+		}
+		if !authorized {
+			unauthorized = append(unauthorized, name)
+		}
+	}
+	return unauthorized
+}
@@ -0,0 +1,238 @@
+package client
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/cpu/acmeshell/acme/resources"
+)
+
+const (
+	// maxAIAFetches is the maximum number of AIA caIssuers URLs VerifyChain
+	// will fetch looking for intermediates missing from a chain.
+	maxAIAFetches = 5
+	// maxPebbleCerts is the maximum number of roots or intermediates
+	// PebbleCertificates will fetch from the Pebble management API.
+	maxPebbleCerts = 10
+)
+
+// VerifyChainOptions control how VerifyChain builds and checks a certificate
+// path.
+type VerifyChainOptions struct {
+	// The trust anchors the path must end in. If nil the system roots are
+	// used.
+	Roots *x509.CertPool
+	// Additional intermediates that may be used to build the path even though
+	// they weren't part of the served chain.
+	Intermediates []*x509.Certificate
+	// If true intermediates referenced by the AIA caIssuers URLs of the chain
+	// are fetched when a path can't be built from the served chain.
+	FetchAIA bool
+	// The identifiers the leaf certificate must be valid for.
+	Identifiers []resources.Identifier
+}
+
+// HostnameCheck is the result of verifying a leaf certificate is valid for an
+// identifier.
+type HostnameCheck struct {
+	// The identifier that was checked.
+	Identifier resources.Identifier
+	// Nil if the certificate is valid for the identifier, otherwise the reason
+	// it isn't.
+	Err error
+}
+
+// ChainVerification is the result of VerifyChain.
+type ChainVerification struct {
+	// The verified path from the leaf certificate to a trusted root. Nil if no
+	// path could be built.
+	Path []*x509.Certificate
+	// The intermediates in the Path that were not part of the served chain.
+	MissingIntermediates []*x509.Certificate
+	// The served certificates that are not part of the Path.
+	UnusedCertificates []*x509.Certificate
+	// The hostname checks for each of the VerifyChainOptions Identifiers.
+	Hostnames []HostnameCheck
+}
+
+// VerifyChain builds and validates a path from the leaf of the given served
+// certificate chain to one of the VerifyChainOptions Roots using crypto/x509.
+// The leaf is the first certificate and the remaining certificates are used as
+// intermediates. The leaf is also checked against each of the
+// VerifyChainOptions Identifiers.
+//
+// A ChainVerification is always returned when certs is not empty, along with
+// a non-nil error if no path to a trusted root could be built.
+func (c *Client) VerifyChain(certs []*x509.Certificate, opts VerifyChainOptions) (*ChainVerification, error) {
+	if len(certs) == 0 {
+		return nil, errors.New("VerifyChain: no certificates to verify")
+	}
+	leaf := certs[0]
+	result := &ChainVerification{}
+	for _, ident := range opts.Identifiers {
+		result.Hostnames = append(result.Hostnames, HostnameCheck{
+			Identifier: ident,
+			Err:        leaf.VerifyHostname(ident.Value),
+		})
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	for _, cert := range opts.Intermediates {
+		intermediates.AddCert(cert)
+	}
+	verifyOpts := x509.VerifyOptions{
+		Roots:         opts.Roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+
+	paths, err := leaf.Verify(verifyOpts)
+	if err != nil && opts.FetchAIA {
+		var unknownAuthority x509.UnknownAuthorityError
+		if errors.As(err, &unknownAuthority) {
+			paths, err = c.verifyWithAIA(certs, verifyOpts)
+		}
+	}
+	if err != nil {
+		return result, fmt.Errorf("VerifyChain: %w", err)
+	}
+
+	// Prefer the shortest path
+	path := paths[0]
+	for _, p := range paths[1:] {
+		if len(p) < len(path) {
+			path = p
+		}
+	}
+	result.Path = path
+
+	// The last certificate of the path is always a trust anchor from the roots
+	// and is never considered a missing intermediate.
+	for i := 1; i < len(path)-1; i++ {
+		if !containsCert(certs, path[i]) {
+			result.MissingIntermediates = append(result.MissingIntermediates, path[i])
+		}
+	}
+	for _, cert := range certs[1:] {
+		if !containsCert(path, cert) {
+			result.UnusedCertificates = append(result.UnusedCertificates, cert)
+		}
+	}
+	return result, nil
+}
+
+// verifyWithAIA fetches the certificates referenced by the AIA caIssuers URLs
+// of the given certificates, adds them to the verifyOpts Intermediates and
+// retries verification of the leaf. Fetched certificates' caIssuers URLs are
+// followed in turn, up to maxAIAFetches.
+func (c *Client) verifyWithAIA(
+	certs []*x509.Certificate,
+	verifyOpts x509.VerifyOptions) ([][]*x509.Certificate, error) {
+	var queue []string
+	for _, cert := range certs {
+		queue = append(queue, cert.IssuingCertificateURL...)
+	}
+
+	seen := map[string]bool{}
+	var lastErr error
+	for fetches := 0; len(queue) > 0 && fetches < maxAIAFetches; {
+		aiaURL := queue[0]
+		queue = queue[1:]
+		if seen[aiaURL] {
+			continue
+		}
+		seen[aiaURL] = true
+		fetches++
+
+		fetched, err := c.fetchAIACertificate(aiaURL)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		verifyOpts.Intermediates.AddCert(fetched)
+		queue = append(queue, fetched.IssuingCertificateURL...)
+
+		paths, err := certs[0].Verify(verifyOpts)
+		if err == nil {
+			return paths, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = errors.New("no AIA caIssuers URLs to fetch")
+	}
+	return nil, lastErr
+}
+
+// fetchAIACertificate fetches a DER or PEM encoded certificate from an AIA
+// caIssuers URL.
+func (c *Client) fetchAIACertificate(aiaURL string) (*x509.Certificate, error) {
+	resp, err := c.GetURL(aiaURL)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching AIA URL %q: %w", aiaURL, err)
+	}
+	if resp.Response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("AIA URL %q returned HTTP status %d",
+			aiaURL, resp.Response.StatusCode)
+	}
+	if cert, err := x509.ParseCertificate(resp.RespBody); err == nil {
+		return cert, nil
+	}
+	certs, err := ParseCertificates(resp.RespBody)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing certificate from AIA URL %q: %w", aiaURL, err)
+	}
+	return certs[0], nil
+}
+
+// PebbleCertificates fetches the root or intermediate certificates from
+// a Pebble ACME server's management API. The kind must be "roots" or
+// "intermediates" and mgmtURL is the management API's base URL (e.g.
+// "https://localhost:15000"). Pebble has one root and intermediate for each
+// certificate chain it offers so certificates are fetched by index until the
+// management API returns an error.
+//
+// See https://github.com/letsencrypt/pebble#ca-root-and-intermediate-certificates
+func (c *Client) PebbleCertificates(mgmtURL string, kind string) ([]*x509.Certificate, error) {
+	if kind != "roots" && kind != "intermediates" {
+		return nil, fmt.Errorf("PebbleCertificates: unknown kind %q", kind)
+	}
+	var certs []*x509.Certificate
+	for i := 0; i < maxPebbleCerts; i++ {
+		certURL := fmt.Sprintf("%s/%s/%d", strings.TrimSuffix(mgmtURL, "/"), kind, i)
+		resp, err := c.GetURL(certURL)
+		if err != nil {
+			return nil, fmt.Errorf("PebbleCertificates: %w", err)
+		}
+		if resp.Response.StatusCode != http.StatusOK {
+			// The first certificate must exist, later indexes may not.
+			if i == 0 {
+				return nil, fmt.Errorf("PebbleCertificates: %q returned HTTP status %d",
+					certURL, resp.Response.StatusCode)
+			}
+			break
+		}
+		parsed, err := ParseCertificates(resp.RespBody)
+		if err != nil {
+			return nil, fmt.Errorf("PebbleCertificates: error parsing %q: %w", certURL, err)
+		}
+		certs = append(certs, parsed...)
+	}
+	return certs, nil
+}
+
+func containsCert(certs []*x509.Certificate, cert *x509.Certificate) bool {
+	for _, c := range certs {
+		if bytes.Equal(c.Raw, cert.Raw) {
+			return true
+		}
+	}
+	return false
+}
@@ -11,8 +11,33 @@ const (
 	NEW_ACCOUNT_ENDPOINT = "newAccount"
 	// The ACME directory key for the newOrder endpoint.
 	NEW_ORDER_ENDPOINT = "newOrder"
+	// The ACME directory key for the optional newAuthz endpoint. See
+	// https://tools.ietf.org/html/rfc8555#section-7.4.1
+	NEW_AUTHZ_ENDPOINT = "newAuthz"
+	// The ACME directory key for the renewalInfo endpoint. See
+	// https://datatracker.ietf.org/doc/html/rfc9773#section-4
+	RENEWAL_INFO_ENDPOINT = "renewalInfo"
 
 	// The HTTP response header used by ACME to communicate a fresh nonce. See
 	// https://tools.ietf.org/html/rfc8555#section-9.3
 	REPLAY_NONCE_HEADER = "Replay-Nonce"
+	// The HTTP response header used by ACME servers to indicate how long
+	// a client should wait before repeating a request. See
+	// https://tools.ietf.org/html/rfc8555#section-8.2
+	RETRY_AFTER_HEADER = "Retry-After"
+
+	// Identifier type constants
+	// See https://tools.ietf.org/html/rfc8555#section-9.7.7
+
+	// The identifier type for fully qualified domain names.
+	DNS_IDENTIFIER = "dns"
+	// The identifier type for IP addresses. See
+	// https://tools.ietf.org/html/rfc8738#section-3
+	IP_IDENTIFIER = "ip"
+
+	// Problem type constants
+	// See https://tools.ietf.org/html/rfc8555#section-6.7
+
+	// The problem type returned when the server rejects a JWS nonce.
+	BAD_NONCE_PROBLEM = "urn:ietf:params:acme:error:badNonce"
 )
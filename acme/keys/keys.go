@@ -3,26 +3,50 @@
 package keys
 
 import (
+	"bytes"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
 	"encoding/pem"
 	"fmt"
+	"strings"
 
 	jose "github.com/go-jose/go-jose/v4"
 )
 
+// KeySpecs are the key specifications accepted by NewSigner. The "ecdsa" and
+// "rsa" key types are also accepted as aliases for "p256" and "rsa2048".
+var KeySpecs = []string{
+	"p256", "p384", "p521", "rsa2048", "rsa3072", "rsa4096", "ed25519",
+}
+
+// sigAlgForKey returns the JWS signature algorithm for the signer. ECDSA keys
+// use the algorithm matching their curve.
+//
+// See https://tools.ietf.org/html/rfc7518#section-3.4 and
+// https://tools.ietf.org/html/rfc8037#section-3.1
 func sigAlgForKey(signer crypto.Signer) jose.SignatureAlgorithm {
-	switch signer.(type) {
+	switch k := signer.(type) {
 	case *ecdsa.PrivateKey:
-		return jose.ES256
+		switch k.Curve {
+		case elliptic.P256():
+			return jose.ES256
+		case elliptic.P384():
+			return jose.ES384
+		case elliptic.P521():
+			return jose.ES512
+		}
 	case *rsa.PrivateKey:
 		return jose.RS256
+	case ed25519.PrivateKey:
+		return jose.EdDSA
 	}
 	return "unknown"
 }
@@ -33,6 +57,8 @@ func algForKey(signer crypto.Signer) string {
 		return "ECDSA"
 	case *rsa.PrivateKey:
 		return "RSA"
+	case ed25519.PrivateKey:
+		return "EdDSA"
 	}
 	return "unknown"
 }
@@ -61,6 +87,15 @@ func KeyAuth(signer crypto.Signer, token string) string {
 	return fmt.Sprintf("%s.%s", token, JWKThumbprint(signer))
 }
 
+// KeyAuthDigest returns the base64url encoded SHA-256 digest of a key
+// authorization. This is the TXT record value used by DNS challenges.
+//
+// See https://tools.ietf.org/html/rfc8555#section-8.4
+func KeyAuthDigest(keyAuth string) string {
+	digest := sha256.Sum256([]byte(keyAuth))
+	return base64.RawURLEncoding.EncodeToString(digest[:])
+}
+
 func JWKForSigner(signer crypto.Signer) jose.JSONWebKey {
 	return jose.JSONWebKey{
 		Key:       signer.Public(),
@@ -91,6 +126,9 @@ func MarshalSigner(signer crypto.Signer) ([]byte, string, error) {
 	case *rsa.PrivateKey:
 		keyType = "rsa"
 		keyBytes = x509.MarshalPKCS1PrivateKey(k)
+	case ed25519.PrivateKey:
+		keyType = "ed25519"
+		keyBytes, err = x509.MarshalPKCS8PrivateKey(k)
 	default:
 		err = fmt.Errorf("signer was unknown type: %T", k)
 	}
@@ -100,14 +138,32 @@ func MarshalSigner(signer crypto.Signer) ([]byte, string, error) {
 	return keyBytes, keyType, nil
 }
 
+// UnmarshalSigner unmarshals a private key of the given key type. The "ecdsa"
+// key type expects SEC1 key bytes, "rsa" expects PKCS#1 key bytes and
+// "ed25519" and "pkcs8" expect PKCS#8 key bytes. PKCS#8 key bytes are also
+// accepted for the "ecdsa" and "rsa" key types.
 func UnmarshalSigner(keyBytes []byte, keyType string) (crypto.Signer, error) {
 	var privKey crypto.Signer
 	var err error
 	switch keyType {
 	case "ecdsa":
 		privKey, err = x509.ParseECPrivateKey(keyBytes)
+		if err != nil {
+			if pkcs8Key, pkcs8Err := parsePKCS8Signer(keyBytes, keyType); pkcs8Err == nil {
+				privKey, err = pkcs8Key, nil
+			}
+		}
 	case "rsa":
 		privKey, err = x509.ParsePKCS1PrivateKey(keyBytes)
+		if err != nil {
+			if pkcs8Key, pkcs8Err := parsePKCS8Signer(keyBytes, keyType); pkcs8Err == nil {
+				privKey, err = pkcs8Key, nil
+			}
+		}
+	case "ed25519":
+		privKey, err = parsePKCS8Signer(keyBytes, keyType)
+	case "pkcs8":
+		privKey, err = parsePKCS8Signer(keyBytes, "")
 	default:
 		err = fmt.Errorf("unknown key type %q", keyType)
 	}
@@ -128,6 +184,9 @@ func SignerToPEM(signer crypto.Signer) (string, error) {
 	case *rsa.PrivateKey:
 		keyBytes = x509.MarshalPKCS1PrivateKey(k)
 		keyHeader = "RSA PRIVATE KEY"
+	case ed25519.PrivateKey:
+		keyBytes, err = x509.MarshalPKCS8PrivateKey(k)
+		keyHeader = "PRIVATE KEY"
 	default:
 		err = fmt.Errorf("unknown key type: %T", k)
 	}
@@ -141,19 +200,150 @@ func SignerToPEM(signer crypto.Signer) (string, error) {
 	return string(pemBytes), nil
 }
 
-func NewSigner(keyType string) (crypto.Signer, error) {
+// PEMToSigner returns the crypto.Signer for the first PEM encoded private key in
+// the given bytes. "EC PRIVATE KEY" (SEC1), "RSA PRIVATE KEY" (PKCS#1) and
+// "PRIVATE KEY" (PKCS#8) PEM block types are supported. An error is returned if
+// there is no PEM block or if the PEM block doesn't contain a supported private
+// key.
+func PEMToSigner(pemBytes []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+
+	switch t := strings.ToUpper(block.Type); t {
+	case "EC PRIVATE KEY":
+		return UnmarshalSigner(block.Bytes, "ecdsa")
+	case "RSA PRIVATE KEY":
+		return UnmarshalSigner(block.Bytes, "rsa")
+	case "PRIVATE KEY":
+		return UnmarshalSigner(block.Bytes, "pkcs8")
+	default:
+		return nil, fmt.Errorf("unknown PEM block type %q", t)
+	}
+}
+
+// DERToSigner returns the crypto.Signer for the given DER encoded private key.
+// PKCS#8, SEC1 and PKCS#1 encodings are tried in that order.
+func DERToSigner(derBytes []byte) (crypto.Signer, error) {
+	for _, keyType := range []string{"pkcs8", "ecdsa", "rsa"} {
+		if signer, err := UnmarshalSigner(derBytes, keyType); err == nil {
+			return signer, nil
+		}
+	}
+	return nil, fmt.Errorf("DER bytes are not a PKCS#8, SEC1 or PKCS#1 private key")
+}
+
+// JWKToSigner returns the crypto.Signer for the given private JWK JSON. An
+// error is returned if the JWK is a public key or a symmetric key.
+func JWKToSigner(jwkBytes []byte) (crypto.Signer, error) {
+	var jwk jose.JSONWebKey
+	if err := jwk.UnmarshalJSON(jwkBytes); err != nil {
+		return nil, err
+	}
+	switch k := jwk.Key.(type) {
+	case *ecdsa.PrivateKey:
+		return k, nil
+	case *rsa.PrivateKey:
+		return k, nil
+	case ed25519.PrivateKey:
+		return k, nil
+	default:
+		return nil, fmt.Errorf("JWK is not a supported private key: %T", k)
+	}
+}
+
+// ParseSigner returns the crypto.Signer for the given private key bytes,
+// detecting the format automatically. Private JWK JSON, PEM (see PEMToSigner)
+// and DER (see DERToSigner) encoded private keys are supported.
+func ParseSigner(keyBytes []byte) (crypto.Signer, error) {
+	trimmed := bytes.TrimSpace(keyBytes)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		return JWKToSigner(trimmed)
+	}
+	if block, _ := pem.Decode(trimmed); block != nil {
+		return PEMToSigner(trimmed)
+	}
+	return DERToSigner(keyBytes)
+}
+
+// SignerToPKCS8PEM returns the PKCS#8 "PRIVATE KEY" PEM encoding of the signer.
+func SignerToPKCS8PEM(signer crypto.Signer) (string, error) {
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(signer)
+	if err != nil {
+		return "", err
+	}
+	pemBytes := pem.EncodeToMemory(&pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: keyBytes,
+	})
+	return string(pemBytes), nil
+}
+
+// PrivateJWKJSON returns the JSON encoding of the signer as a private JWK.
+func PrivateJWKJSON(signer crypto.Signer) (string, error) {
+	jwk := jose.JSONWebKey{
+		Key:       signer,
+		Algorithm: string(sigAlgForKey(signer)),
+	}
+	jwkJSON, err := json.Marshal(&jwk)
+	if err != nil {
+		return "", err
+	}
+	return string(jwkJSON), nil
+}
+
+// NewSigner generates a new random private key for the given key
+// specification. See KeySpecs for the supported key specifications.
+func NewSigner(keySpec string) (crypto.Signer, error) {
 	var randKey crypto.Signer
 	var err error
-	switch keyType {
-	case "ecdsa":
+	switch strings.ToLower(keySpec) {
+	case "ecdsa", "p256":
 		randKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
-	case "rsa":
+	case "p384":
+		randKey, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case "p521":
+		randKey, err = ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	case "rsa", "rsa2048":
 		randKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "rsa3072":
+		randKey, err = rsa.GenerateKey(rand.Reader, 3072)
+	case "rsa4096":
+		randKey, err = rsa.GenerateKey(rand.Reader, 4096)
+	case "ed25519":
+		_, randKey, err = ed25519.GenerateKey(rand.Reader)
 	default:
-		err = fmt.Errorf("unknown key type: %q", keyType)
+		err = fmt.Errorf("unknown key type: %q. Supported key types: %s",
+			keySpec, strings.Join(KeySpecs, ", "))
 	}
 	if err != nil {
 		return nil, err
 	}
 	return randKey, nil
 }
+
+// parsePKCS8Signer parses a PKCS#8 encoded private key. If keyType is not
+// empty the key must be of that type ("ecdsa", "rsa" or "ed25519").
+func parsePKCS8Signer(keyBytes []byte, keyType string) (crypto.Signer, error) {
+	key, err := x509.ParsePKCS8PrivateKey(keyBytes)
+	if err != nil {
+		return nil, err
+	}
+	var signer crypto.Signer
+	var signerType string
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		signer, signerType = k, "ecdsa"
+	case *rsa.PrivateKey:
+		signer, signerType = k, "rsa"
+	case ed25519.PrivateKey:
+		signer, signerType = k, "ed25519"
+	default:
+		return nil, fmt.Errorf("PKCS#8 key was unsupported type %T", key)
+	}
+	if keyType != "" && keyType != signerType {
+		return nil, fmt.Errorf("PKCS#8 key was type %q, not %q", signerType, keyType)
+	}
+	return signer, nil
+}
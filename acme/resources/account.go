@@ -20,8 +20,11 @@ import (
 // time of account creation and used as the JWS KeyID for authenticating ACME
 // requests with the Account's registered keypair.
 //
-// The Contact field is either nil or a slice of one or more email addresses
-// to be used as the ACME Account's "mailto://" Contact addresses.
+// The Contact field is either nil or a slice of one or more contact URIs
+// (e.g. "mailto:admin@example.com") for the ACME Account.
+//
+// The Status field holds the Account status most recently returned by the ACME
+// server (if any).
 //
 // The Signer field is a pointer to a private key used for the ACME
 // account's keypair. The public component is computed from this private key
@@ -31,21 +34,35 @@ import (
 // These URLs correspond to Orders that the Account created with the ACME
 // server.
 //
+// The Authzs field is either nil or a slice of one or more Authorization
+// resource URLs. These URLs correspond to standalone Authorizations that the
+// Account created with the ACME server's newAuthz endpoint (pre-authorization).
+//
+// The FinalizeKeys field is either nil or a map from Order resource URLs to the
+// ID of the key that the CSR used to finalize the Order was created with.
+//
 // For information about the Account resource see
 // https://tools.ietf.org/html/rfc8555#section-7.1.2
 type Account struct {
 	// The server assigned Account ID. This is used for the JWS KeyID when
 	// authenticating ACME requests using the Account's registered keypair.
 	ID string `json:"id"`
-	// If not nil, a slice of one or more email addresses to be used as the ACME
-	// Account's "mailto://" Contact addresses.
+	// If not nil, a slice of one or more contact URIs for the ACME Account.
 	Contact []string `json:"contact"`
+	// The Account status most recently returned by the ACME server (if any).
+	Status string `json:"status,omitempty"`
 	// A signer to use to sign protocol messages and to access the ACME account's
 	// public key
 	Signer crypto.Signer
 	// If not nil, a slice of URLs for Order resources the Account created with
 	// the ACME server.
 	Orders []string `json:"orders"`
+	// If not nil, a slice of URLs for standalone Authorization resources the
+	// Account created with the ACME server's newAuthz endpoint.
+	Authzs []string `json:"authzs,omitempty"`
+	// If not nil, a map from Order URLs to the ID of the key used for the CSR
+	// that finalized the Order.
+	FinalizeKeys map[string]string `json:"finalizeKeys,omitempty"`
 	// The JSON path backing the account (if any)
 	jsonPath string
 }
@@ -72,6 +89,41 @@ func (a *Account) OrderURL(i int) (string, error) {
 	return a.Orders[i], nil
 }
 
+// AuthzURL returns the Authorization URL for the ith standalone Authorization
+// the Account owns. An error is returned if the Account has no standalone
+// Authorizations or if the index is out of bounds.
+func (a *Account) AuthzURL(i int) (string, error) {
+	if len(a.Authzs) == 0 {
+		return "", errors.New("Account has no standalone authorizations")
+	}
+	if i < 0 || i >= len(a.Authzs) {
+		return "", fmt.Errorf("Authz index must be 0 < x < %d", len(a.Authzs))
+	}
+	return a.Authzs[i], nil
+}
+
+// FinalizeKeyID returns the ID of the key used for the CSR that finalized the
+// Order with the given URL and true. If no key ID was recorded for the Order an
+// empty string and false are returned.
+func (a *Account) FinalizeKeyID(orderURL string) (string, bool) {
+	keyID, found := a.FinalizeKeys[orderURL]
+	return keyID, found
+}
+
+// SetFinalizeKeyID records the ID of the key used for the CSR that finalized
+// the Order with the given URL. An empty keyID removes any recorded key ID, for
+// example when the Order was finalized with a CSR for an unknown key.
+func (a *Account) SetFinalizeKeyID(orderURL string, keyID string) {
+	if keyID == "" {
+		delete(a.FinalizeKeys, orderURL)
+		return
+	}
+	if a.FinalizeKeys == nil {
+		a.FinalizeKeys = map[string]string{}
+	}
+	a.FinalizeKeys[orderURL] = keyID
+}
+
 // NewAccount creates an ACME account in-memory. *Important:* the
 // created Account is *not* registered with the ACME server until
 // it is explicitly "created" server-side using a Client instance's
@@ -127,12 +179,18 @@ func SaveAccount(path string, account *Account) error {
 	return os.WriteFile(path, frozenBytes, 0600)
 }
 
+// rawAccount is the saved form of an Account. The KeyType is one of the key
+// types supported by keys.UnmarshalSigner. Save files written by other tools
+// may use a "pkcs8" KeyType with PKCS#8 encoded PrivateKey bytes.
 type rawAccount struct {
-	ID         string
-	Contact    []string
-	Orders     []string
-	KeyType    string
-	PrivateKey []byte
+	ID           string
+	Contact      []string
+	Status       string `json:",omitempty"`
+	Orders       []string
+	Authzs       []string          `json:",omitempty"`
+	FinalizeKeys map[string]string `json:",omitempty"`
+	KeyType      string
+	PrivateKey   []byte
 }
 
 func (a *Account) save() ([]byte, error) {
@@ -142,11 +200,14 @@ func (a *Account) save() ([]byte, error) {
 	}
 
 	rawAcct := rawAccount{
-		ID:         a.ID,
-		Contact:    a.Contact,
-		Orders:     a.Orders,
-		KeyType:    keyType,
-		PrivateKey: keyBytes,
+		ID:           a.ID,
+		Contact:      a.Contact,
+		Status:       a.Status,
+		Orders:       a.Orders,
+		Authzs:       a.Authzs,
+		FinalizeKeys: a.FinalizeKeys,
+		KeyType:      keyType,
+		PrivateKey:   keyBytes,
 	}
 	frozenAcct, err := json.MarshalIndent(rawAcct, "", "  ")
 	if err != nil {
@@ -186,7 +247,10 @@ func (a *Account) restore(frozenAcct []byte) error {
 
 	a.ID = rawAcct.ID
 	a.Contact = rawAcct.Contact
+	a.Status = rawAcct.Status
 	a.Orders = rawAcct.Orders
+	a.Authzs = rawAcct.Authzs
+	a.FinalizeKeys = rawAcct.FinalizeKeys
 	a.Signer = privKey
 	return nil
 }
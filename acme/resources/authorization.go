@@ -1,5 +1,13 @@
 package resources
 
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/cpu/acmeshell/acme"
+)
+
 // The Identifier resource represents a subject identifier that can be included
 // in a certificate.
 //
@@ -8,7 +16,9 @@ package resources
 // https://tools.ietf.org/html/rfc8555#section-9.7.7
 //
 // In practice most ACME servers only support "DNS" type identifiers where the
-// value specifies a fully qualified domain name.
+// value specifies a fully qualified domain name. Some servers also support "IP"
+// type identifiers where the value specifies an IPv4 or IPv6 address. See
+// https://tools.ietf.org/html/rfc8738
 //
 // A DNS type identifier that is used in a NewOrder request is allowed to
 // contain a wildcard prefix (e.g. "*."). A DNS type identifier that is used in
@@ -22,6 +32,69 @@ type Identifier struct {
 	Value string `json:"value"`
 }
 
+// NewIdentifier returns an Identifier for the given value. If the value is an
+// IP address an "ip" type Identifier is returned with the address in its
+// canonical textual form. Otherwise a "dns" type Identifier is returned.
+func NewIdentifier(value string) Identifier {
+	value = strings.TrimSpace(value)
+	if ip := net.ParseIP(value); ip != nil {
+		return Identifier{
+			Type:  acme.IP_IDENTIFIER,
+			Value: ip.String(),
+		}
+	}
+	return Identifier{
+		Type:  acme.DNS_IDENTIFIER,
+		Value: value,
+	}
+}
+
+// Matches returns true if the Identifier's value matches the given value. IP
+// addresses are compared by address so that different textual forms of the
+// same IPv6 address match. DNS names are compared case-insensitively.
+func (i Identifier) Matches(value string) bool {
+	value = strings.TrimSpace(value)
+	if i.Value == value {
+		return true
+	}
+	if ip := net.ParseIP(i.Value); ip != nil {
+		return ip.Equal(net.ParseIP(value))
+	}
+	return i.Type == acme.DNS_IDENTIFIER && strings.EqualFold(i.Value, value)
+}
+
+// ReverseDNSName returns the reverse DNS name of an "ip" type Identifier's
+// address (e.g. "4.3.2.1.in-addr.arpa") without a trailing dot. This is the
+// TLS SNI value used for tls-alpn-01 validation of IP identifiers. An empty
+// string is returned for other identifier types.
+//
+// See https://tools.ietf.org/html/rfc8738#section-6
+func (i Identifier) ReverseDNSName() string {
+	if i.Type != acme.IP_IDENTIFIER {
+		return ""
+	}
+	ip := net.ParseIP(i.Value)
+	if ip == nil {
+		return ""
+	}
+
+	var labels []string
+	if v4 := ip.To4(); v4 != nil {
+		for j := len(v4) - 1; j >= 0; j-- {
+			labels = append(labels, strconv.Itoa(int(v4[j])))
+		}
+		return strings.Join(labels, ".") + ".in-addr.arpa"
+	}
+
+	const hexDigits = "0123456789abcdef"
+	for j := len(ip) - 1; j >= 0; j-- {
+		labels = append(labels,
+			string(hexDigits[ip[j]&0x0F]),
+			string(hexDigits[ip[j]>>4]))
+	}
+	return strings.Join(labels, ".") + ".ip6.arpa"
+}
+
 // The ACME Authorization resource represents an Account's authorization to
 // issue for a specified identifier, based on interactions with associated
 // Challenges. Authorization for an identifier allows issuing certificates
@@ -1,5 +1,11 @@
 package resources
 
+import (
+	"crypto/sha256"
+	"encoding/base32"
+	"strings"
+)
+
 // The ACME Challenge resource represents an action that the client must take to
 // authorize a given account for a specific identifier in order to issue
 // a certificate containing that identifier.
@@ -13,7 +19,8 @@ package resources
 // To understand the Challenge Status changes specified by RFC 8555 see
 // https://tools.ietf.org/html/rfc8555#section-7.1.6
 type Challenge struct {
-	// The Type of the challenge (expected values include "http-01", "dns-01", "tls-alpn-01")
+	// The Type of the challenge (expected values include "http-01", "dns-01",
+	// "dns-account-01", "tls-alpn-01")
 	Type string `json:"type"`
 	// The URL/ID of the challenge (provided by the server in the associated
 	// Authorization)
@@ -33,3 +40,16 @@ type Challenge struct {
 func (c Challenge) String() string {
 	return c.URL
 }
+
+// DNSAccountChallengeName returns the name of the TXT record used to solve
+// a dns-account-01 challenge for the given domain by the account with the given
+// URL. The name is "_<label>._acme-challenge.<domain>" where the label is the
+// lowercase base32 encoding of the first 10 bytes of the SHA-256 digest of the
+// account URL. The returned name has no trailing dot.
+//
+// See https://datatracker.ietf.org/doc/draft-ietf-acme-dns-account-label/
+func DNSAccountChallengeName(accountURL string, domain string) string {
+	digest := sha256.Sum256([]byte(accountURL))
+	label := strings.ToLower(base32.StdEncoding.EncodeToString(digest[:10]))
+	return "_" + label + "._acme-challenge." + strings.TrimSuffix(domain, ".")
+}
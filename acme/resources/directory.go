@@ -0,0 +1,239 @@
+package resources
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+)
+
+// requiredEndpoints are the directory endpoints that every RFC 8555 ACME
+// server must provide.
+//
+// See https://tools.ietf.org/html/rfc8555#section-7.1.1
+var requiredEndpoints = []string{
+	"newNonce",
+	"newAccount",
+	"newOrder",
+	"revokeCert",
+	"keyChange",
+}
+
+// DirectoryMeta holds the optional metadata of an ACME Directory.
+//
+// For information about the directory meta object see
+// https://tools.ietf.org/html/rfc8555#section-7.1.1
+type DirectoryMeta struct {
+	// A URL identifying the current terms of service.
+	TermsOfService string `json:"termsOfService,omitempty"`
+	// A URL for a website with more information about the ACME server.
+	Website string `json:"website,omitempty"`
+	// The hostnames the ACME server recognizes as referring to itself for the
+	// purposes of CAA record validation.
+	CAAIdentities []string `json:"caaIdentities,omitempty"`
+	// Whether the ACME server requires newAccount requests to include an
+	// external account binding.
+	ExternalAccountRequired bool `json:"externalAccountRequired,omitempty"`
+	// The certificate profiles offered by the ACME server, keyed by profile name
+	// with a description as the value. See
+	// https://datatracker.ietf.org/doc/draft-ietf-acme-profiles/
+	Profiles map[string]string `json:"profiles,omitempty"`
+	// Any meta fields not represented above, keyed by field name.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON unmarshals a DirectoryMeta, keeping unknown fields in Extra.
+func (m *DirectoryMeta) UnmarshalJSON(data []byte) error {
+	type meta DirectoryMeta
+	var known meta
+	if err := json.Unmarshal(data, &known); err != nil {
+		return err
+	}
+	extra, err := unknownFields(data, "termsOfService", "website",
+		"caaIdentities", "externalAccountRequired", "profiles")
+	if err != nil {
+		return err
+	}
+	*m = DirectoryMeta(known)
+	m.Extra = extra
+	return nil
+}
+
+// MarshalJSON marshals a DirectoryMeta, including the fields kept in Extra.
+func (m DirectoryMeta) MarshalJSON() ([]byte, error) {
+	type meta DirectoryMeta
+	return marshalWithExtra(meta(m), m.Extra)
+}
+
+// Directory is an ACME server's directory resource. It describes the URLs of
+// the ACME server's endpoints and optional metadata about the server.
+//
+// For information about the Directory resource see
+// https://tools.ietf.org/html/rfc8555#section-7.1.1
+type Directory struct {
+	// The URL of the newNonce endpoint.
+	NewNonce string `json:"newNonce,omitempty"`
+	// The URL of the newAccount endpoint.
+	NewAccount string `json:"newAccount,omitempty"`
+	// The URL of the newOrder endpoint.
+	NewOrder string `json:"newOrder,omitempty"`
+	// The URL of the optional newAuthz endpoint.
+	NewAuthz string `json:"newAuthz,omitempty"`
+	// The URL of the revokeCert endpoint.
+	RevokeCert string `json:"revokeCert,omitempty"`
+	// The URL of the keyChange endpoint.
+	KeyChange string `json:"keyChange,omitempty"`
+	// The URL of the optional renewalInfo endpoint. See
+	// https://datatracker.ietf.org/doc/html/rfc9773#section-4
+	RenewalInfo string `json:"renewalInfo,omitempty"`
+	// The optional directory metadata.
+	Meta *DirectoryMeta `json:"meta,omitempty"`
+	// Any directory fields not represented above, keyed by field name. These
+	// are typically endpoints from ACME extensions.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON unmarshals a Directory, keeping unknown fields in Extra.
+func (d *Directory) UnmarshalJSON(data []byte) error {
+	type directory Directory
+	var known directory
+	if err := json.Unmarshal(data, &known); err != nil {
+		return err
+	}
+	extra, err := unknownFields(data, "newNonce", "newAccount", "newOrder",
+		"newAuthz", "revokeCert", "keyChange", "renewalInfo", "meta")
+	if err != nil {
+		return err
+	}
+	*d = Directory(known)
+	d.Extra = extra
+	return nil
+}
+
+// MarshalJSON marshals a Directory, including the fields kept in Extra.
+func (d Directory) MarshalJSON() ([]byte, error) {
+	type directory Directory
+	return marshalWithExtra(directory(d), d.Extra)
+}
+
+// Endpoints returns a map of the Directory's endpoint names to their URLs. This
+// includes the endpoints from the Extra fields that have a string value. Empty
+// endpoints are omitted.
+func (d Directory) Endpoints() map[string]string {
+	endpoints := map[string]string{
+		"newNonce":    d.NewNonce,
+		"newAccount":  d.NewAccount,
+		"newOrder":    d.NewOrder,
+		"newAuthz":    d.NewAuthz,
+		"revokeCert":  d.RevokeCert,
+		"keyChange":   d.KeyChange,
+		"renewalInfo": d.RenewalInfo,
+	}
+	for name, raw := range d.Extra {
+		var v string
+		if err := json.Unmarshal(raw, &v); err == nil {
+			endpoints[name] = v
+		}
+	}
+	for name, v := range endpoints {
+		if v == "" {
+			delete(endpoints, name)
+		}
+	}
+	return endpoints
+}
+
+// Endpoint returns the URL of the endpoint with the given name and true. If the
+// Directory has no such endpoint an empty string and false are returned.
+func (d Directory) Endpoint(name string) (string, bool) {
+	v, ok := d.Endpoints()[name]
+	return v, ok
+}
+
+// Validate checks the Directory for problems. An error is returned for each
+// required endpoint that is missing and for each endpoint, terms of service or
+// website URL that is not an absolute HTTPS URL. A nil slice is returned if
+// there are no problems.
+func (d Directory) Validate() []error {
+	var problems []error
+	endpoints := d.Endpoints()
+	for _, name := range requiredEndpoints {
+		if _, ok := endpoints[name]; !ok {
+			problems = append(problems, fmt.Errorf("missing required endpoint %q", name))
+		}
+	}
+
+	names := make([]string, 0, len(endpoints))
+	for name := range endpoints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := checkHTTPSURL(endpoints[name]); err != nil {
+			problems = append(problems, fmt.Errorf("endpoint %q: %w", name, err))
+		}
+	}
+
+	if d.Meta != nil {
+		if d.Meta.TermsOfService != "" {
+			if err := checkHTTPSURL(d.Meta.TermsOfService); err != nil {
+				problems = append(problems, fmt.Errorf("meta termsOfService: %w", err))
+			}
+		}
+		if d.Meta.Website != "" {
+			if err := checkHTTPSURL(d.Meta.Website); err != nil {
+				problems = append(problems, fmt.Errorf("meta website: %w", err))
+			}
+		}
+	}
+	return problems
+}
+
+// checkHTTPSURL returns an error if the given string is not an absolute URL with
+// a HTTPS scheme.
+func checkHTTPSURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("URL %q is not an absolute HTTPS URL", rawURL)
+	}
+	return nil
+}
+
+// unknownFields returns the fields of the given JSON object that are not one of
+// the known field names. A nil map is returned if there are no unknown fields.
+func unknownFields(data []byte, known ...string) (map[string]json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for _, name := range known {
+		delete(fields, name)
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return fields, nil
+}
+
+// marshalWithExtra marshals the given value to a JSON object and adds the extra
+// fields to it. Known fields take precedence over extra fields with the same
+// name.
+func marshalWithExtra(v any, extra map[string]json.RawMessage) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for name, raw := range extra {
+		if _, found := fields[name]; !found {
+			fields[name] = raw
+		}
+	}
+	return json.Marshal(fields)
+}
@@ -17,7 +17,8 @@ type Order struct {
 	// The Error associated with an invalid order
 	Error *Problem `json:"error,omitempty"`
 	// NotBefore and NotAfter are the requested values of the notBefore and
-	// notAfter fields of the resulting certificate. Ignored by Boulder.
+	// notAfter fields of the resulting certificate as RFC 3339 timestamps.
+	// Ignored by Boulder.
 	NotBefore string `json:"notBefore,omitempty"`
 	NotAfter  string `json:"notAfter,omitempty"`
 	// The Identifiers the Order wishes to finalize a Certificate for once the
@@ -33,6 +34,14 @@ type Order struct {
 	// after being Finalized. The Certificate field should be present and
 	// not-empty when the Order has a status of "valid".
 	Certificate string `json:"certificate,omitempty"`
+	// An optional ACME Renewal Information (ARI) certificate identifier for
+	// a previously issued certificate that this Order is replacing. See
+	// https://datatracker.ietf.org/doc/html/rfc9773#section-5
+	Replaces string `json:"replaces,omitempty"`
+	// An optional name of a certificate profile advertised in the server's
+	// directory meta "profiles" object. See
+	// https://datatracker.ietf.org/doc/draft-ietf-acme-profiles/
+	Profile string `json:"profile,omitempty"`
 }
 
 // String returns the Order's ID URL.
@@ -1,10 +1,73 @@
 package resources
 
+import (
+	"fmt"
+	"strings"
+)
+
 // Problem is a struct representing a problem document from the server.
 //
-// TODO(@cpu): implement RFC 8555 subproblem support
+// For information about ACME problem documents see
+// https://tools.ietf.org/html/rfc8555#section-6.7
 type Problem struct {
-	Type   string `json:"type"`
+	// The problem type URN (e.g. "urn:ietf:params:acme:error:malformed").
+	Type string `json:"type"`
+	// A human readable description of the problem.
+	Detail string `json:"detail"`
+	// The HTTP status code the server used for the problem (if any).
+	Status int `json:"status"`
+	// An optional URL identifying the specific occurrence of the problem.
+	Instance string `json:"instance,omitempty"`
+	// Optional subproblems with more detail about the problem as it relates to
+	// specific identifiers.
+	//
+	// See https://tools.ietf.org/html/rfc8555#section-6.7.1
+	Subproblems []Subproblem `json:"subproblems,omitempty"`
+}
+
+// Subproblem is a struct representing one of the subproblems of a problem
+// document from the server. Each subproblem has its own type and detail, and
+// may reference the identifier it relates to.
+//
+// See https://tools.ietf.org/html/rfc8555#section-6.7.1
+type Subproblem struct {
+	// The subproblem type URN.
+	Type string `json:"type"`
+	// A human readable description of the subproblem.
 	Detail string `json:"detail"`
-	Status int    `json:"status"`
+	// The HTTP status code the server associated with the subproblem (if any).
+	Status int `json:"status,omitempty"`
+	// The identifier the subproblem relates to (if any).
+	Identifier *Identifier `json:"identifier,omitempty"`
+}
+
+// String returns a human readable representation of the Subproblem on one line.
+func (s Subproblem) String() string {
+	var ident string
+	if s.Identifier != nil {
+		ident = fmt.Sprintf("%s %q: ", s.Identifier.Type, s.Identifier.Value)
+	}
+	return fmt.Sprintf("%s%s :: %s", ident, s.Type, s.Detail)
+}
+
+// String returns a human readable representation of the Problem. The first
+// line has the problem type, status and detail. Following lines have the
+// instance URL and each subproblem (if any).
+func (p Problem) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s", p.Type)
+	if p.Status != 0 {
+		fmt.Fprintf(&b, " (HTTP %d)", p.Status)
+	}
+	fmt.Fprintf(&b, " :: %s", p.Detail)
+	if p.Instance != "" {
+		fmt.Fprintf(&b, "\n  Instance: %s", p.Instance)
+	}
+	if len(p.Subproblems) > 0 {
+		fmt.Fprintf(&b, "\n  Subproblems:")
+		for _, sub := range p.Subproblems {
+			fmt.Fprintf(&b, "\n    - %s", sub)
+		}
+	}
+	return b.String()
 }
@@ -0,0 +1,29 @@
+package resources
+
+import "time"
+
+// RenewalWindow is a pair of timestamps describing the period of time in which
+// an ACME server suggests a certificate be renewed.
+type RenewalWindow struct {
+	// The start of the suggested renewal window.
+	Start time.Time `json:"start"`
+	// The end of the suggested renewal window.
+	End time.Time `json:"end"`
+}
+
+// RenewalInfo holds an ACME Renewal Information (ARI) response describing when
+// the ACME server would like a certificate to be renewed.
+//
+// For information about the RenewalInfo resource see
+// https://datatracker.ietf.org/doc/html/rfc9773#section-4.2
+type RenewalInfo struct {
+	// The window in which the server suggests the certificate be renewed.
+	SuggestedWindow RenewalWindow `json:"suggestedWindow"`
+	// An optional URL pointing to a page explaining why the suggested window
+	// is what it is (e.g. an incident report for a mass revocation).
+	ExplanationURL string `json:"explanationURL,omitempty"`
+	// The time before which the server asked that the renewal information not be
+	// requested again, computed from the response's Retry-After header. It is
+	// the zero time if the server did not send a Retry-After header.
+	RetryAfter time.Time `json:"-"`
+}
@@ -135,6 +135,36 @@ func main() {
 		true,
 		"Use POST-as-GET requests instead of GET requests in high level commands")
 
+	retryBadNonce := flag.Bool(
+		"retryBadNonce",
+		true,
+		"Retry signed requests once with a fresh nonce when the server returns a badNonce error")
+
+	eabKeyID := flag.String(
+		"eabKID",
+		"",
+		"Optional external account binding key ID for created ACME accounts")
+
+	eabKey := flag.String(
+		"eabKey",
+		"",
+		"Optional base64url encoded external account binding MAC key for created ACME accounts")
+
+	eabAlg := flag.String(
+		"eabAlg",
+		"HS256",
+		"External account binding MAC algorithm (HS256, HS384 or HS512)")
+
+	output := flag.String(
+		"output",
+		"text",
+		"Output format of command results: text or json (one JSON object per command)")
+
+	harPath := flag.String(
+		"har",
+		"",
+		"Optional file path to save an HTTP Archive (HAR) recording of all HTTP requests and responses to on exit")
+
 	flag.Parse()
 
 	if *pebble {
@@ -169,12 +199,16 @@ func main() {
 
 	config := &acmeshell.ACMEShellOptions{
 		ClientConfig: acmeclient.ClientConfig{
-			DirectoryURL: *directory,
-			CACert:       *caCert,
-			ContactEmail: *email,
-			AccountPath:  *acctPath,
-			AutoRegister: *autoRegister,
-			POSTAsGET:    *postAsGet,
+			DirectoryURL:  *directory,
+			CACert:        *caCert,
+			ContactEmail:  *email,
+			AccountPath:   *acctPath,
+			AutoRegister:  *autoRegister,
+			POSTAsGET:     *postAsGet,
+			RetryBadNonce: *retryBadNonce,
+			EABKeyID:      *eabKeyID,
+			EABKey:        *eabKey,
+			EABAlgorithm:  *eabAlg,
 			InitialOutput: acmeclient.OutputOptions{
 				PrintRequests:     *printRequests,
 				PrintResponses:    *printResponses,
@@ -187,6 +221,8 @@ func main() {
 		HTTPPort: *httpPort,
 		TLSPort:  *tlsPort,
 		DNSPort:  *dnsPort,
+		Output:   *output,
+		HARPath:  *harPath,
 	}
 
 	shell := acmeshell.NewACMEShell(config)
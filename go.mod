@@ -5,6 +5,7 @@ require (
 	github.com/abiosoft/readline v0.0.0-20180607040430-155bce2042db
 	github.com/go-jose/go-jose/v4 v4.1.2
 	github.com/letsencrypt/challtestsrv v1.3.3
+	golang.org/x/crypto v0.41.0
 )
 
 require (
@@ -18,7 +19,6 @@ require (
 	github.com/miekg/dns v1.1.68 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/stretchr/testify v1.2.2 // indirect
-	golang.org/x/crypto v0.41.0 // indirect
 	golang.org/x/mod v0.27.0 // indirect
 	golang.org/x/net v0.43.0 // indirect
 	golang.org/x/sync v0.16.0 // indirect
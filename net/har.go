@@ -0,0 +1,517 @@
+package net
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// harVersion is the version of the HTTP Archive format written by
+// HARRecorder. See http://www.softwareishard.com/blog/har-12-spec/
+const harVersion = "1.2"
+
+// HARRecorder records HTTP requests and responses in the HTTP Archive (HAR)
+// format. Requests are only recorded between calls to Start and Stop. A
+// HARRecorder is safe for concurrent use and may be shared by several ACMENet
+// instances.
+type HARRecorder struct {
+	mu        sync.Mutex
+	recording bool
+	entries   []harEntry
+}
+
+// NewHARRecorder creates a HARRecorder that is not recording.
+func NewHARRecorder() *HARRecorder {
+	return &HARRecorder{}
+}
+
+// Start begins a new recording, discarding any previously recorded entries.
+func (r *HARRecorder) Start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recording = true
+	r.entries = nil
+}
+
+// Stop stops recording. The recorded entries are kept until the next Start.
+func (r *HARRecorder) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recording = false
+}
+
+// Recording returns true if the HARRecorder is recording.
+func (r *HARRecorder) Recording() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.recording
+}
+
+// Len returns the number of recorded entries.
+func (r *HARRecorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.entries)
+}
+
+// MarshalJSON returns the recorded entries as a HAR document. The entries are
+// sorted by the time their request was started.
+func (r *HARRecorder) MarshalJSON() ([]byte, error) {
+	r.mu.Lock()
+	entries := make([]harEntry, len(r.entries))
+	copy(entries, r.entries)
+	r.mu.Unlock()
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartedDateTime.Before(entries[j].StartedDateTime)
+	})
+	return json.Marshal(harDocument{
+		Log: harLog{
+			Version: harVersion,
+			Creator: harCreator{
+				Name:    userAgentBase,
+				Version: version,
+			},
+			Entries: entries,
+		},
+	})
+}
+
+// Save writes the recorded entries to the given file path as a HAR document.
+func (r *HARRecorder) Save(path string) error {
+	harJSON, err := r.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, harJSON, "", "  "); err != nil {
+		return err
+	}
+	return os.WriteFile(path, indented.Bytes(), os.ModePerm)
+}
+
+func (r *HARRecorder) add(entry harEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.recording {
+		r.entries = append(r.entries, entry)
+	}
+}
+
+// RecordTo makes the ACMENet record all of its HTTP requests and responses,
+// including HEAD requests, with the given HARRecorder while it is recording.
+func (c *ACMENet) RecordTo(recorder *HARRecorder) {
+	transport := c.httpClient.Transport
+	if recording, ok := transport.(*harTransport); ok {
+		transport = recording.next
+	}
+	c.httpClient.Transport = &harTransport{
+		next:     transport,
+		recorder: recorder,
+	}
+}
+
+// harTransport is an http.RoundTripper that records the round trips of the
+// next http.RoundTripper with a HARRecorder.
+type harTransport struct {
+	next     http.RoundTripper
+	recorder *HARRecorder
+}
+
+func (t *harTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.recorder.Recording() {
+		return t.next.RoundTrip(req)
+	}
+
+	var reqBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	timer := &harTimer{start: time.Now()}
+	traced := req.Clone(httptrace.WithClientTrace(req.Context(), timer.trace()))
+	if reqBody != nil {
+		traced.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	entry := harEntry{
+		StartedDateTime: timer.start,
+		Request:         newHARRequest(req, reqBody),
+		Cache:           struct{}{},
+		JWS:             decodeJWS(req.Header.Get("Content-Type"), reqBody),
+	}
+
+	resp, err := t.next.RoundTrip(traced)
+	if err != nil {
+		entry.Response = harResponse{
+			Cookies: []harCookie{},
+			Headers: []harNameValue{},
+			Content: harContent{
+				MimeType: "x-unknown",
+			},
+			HeadersSize: -1,
+			BodySize:    -1,
+		}
+		entry.Error = err.Error()
+		entry.Timings, entry.Time = timer.timings(time.Now())
+		entry.ServerIPAddress = timer.serverIP()
+		t.recorder.add(entry)
+		return nil, err
+	}
+
+	// The response body is read here so that the time taken to receive it is
+	// included in the timings. The caller is given a copy.
+	respBody, readErr := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	entry.Response = newHARResponse(resp, respBody)
+	if readErr != nil {
+		entry.Error = readErr.Error()
+	}
+	entry.Timings, entry.Time = timer.timings(time.Now())
+	entry.ServerIPAddress = timer.serverIP()
+	t.recorder.add(entry)
+
+	// A RoundTripper must not return both a response and an error.
+	if readErr != nil {
+		return nil, readErr
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	return resp, nil
+}
+
+// harTimer collects the timings of a single round trip with an
+// httptrace.ClientTrace. The trace hooks may be called concurrently.
+type harTimer struct {
+	start time.Time
+
+	mu           sync.Mutex
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	gotConn      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+	remoteAddr   string
+}
+
+func (t *harTimer) set(field *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if field.IsZero() {
+		*field = time.Now()
+	}
+}
+
+func (t *harTimer) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { t.set(&t.dnsStart) },
+		DNSDone:           func(httptrace.DNSDoneInfo) { t.set(&t.dnsDone) },
+		ConnectStart:      func(string, string) { t.set(&t.connectStart) },
+		ConnectDone:       func(string, string, error) { t.set(&t.connectDone) },
+		TLSHandshakeStart: func() { t.set(&t.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { t.set(&t.tlsDone) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.set(&t.gotConn)
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if info.Conn != nil {
+				t.remoteAddr = info.Conn.RemoteAddr().String()
+			}
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { t.set(&t.wroteRequest) },
+		GotFirstResponseByte: func() { t.set(&t.firstByte) },
+	}
+}
+
+// timings returns the HAR timings of a round trip that ended at the given time
+// and the total time of the round trip in milliseconds. Phases that did not
+// happen are -1. Time not accounted for by the other phases is reported as
+// blocked.
+func (t *harTimer) timings(end time.Time) (harTimings, float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	timings := harTimings{
+		DNS:     phase(t.dnsStart, t.dnsDone),
+		Connect: phase(t.connectStart, t.tlsDone),
+		Send:    phase(t.gotConn, t.wroteRequest),
+		Wait:    phase(t.wroteRequest, t.firstByte),
+		Receive: phase(t.firstByte, end),
+		SSL:     phase(t.tlsStart, t.tlsDone),
+	}
+	// The connect phase includes the TLS handshake, if there was one.
+	if t.tlsDone.IsZero() {
+		timings.Connect = phase(t.connectStart, t.connectDone)
+	}
+
+	total := milliseconds(end.Sub(t.start))
+	blocked := total
+	for _, ms := range []float64{
+		timings.DNS, timings.Connect, timings.Send, timings.Wait, timings.Receive,
+	} {
+		if ms > 0 {
+			blocked -= ms
+		}
+	}
+	// Round away floating point noise from the subtractions.
+	timings.Blocked = max(math.Round(blocked*1000)/1000, 0)
+	return timings, total
+}
+
+func (t *harTimer) serverIP() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.remoteAddr == "" {
+		return ""
+	}
+	host := t.remoteAddr
+	if i := strings.LastIndex(host, ":"); i != -1 {
+		host = host[:i]
+	}
+	return strings.Trim(host, "[]")
+}
+
+// phase returns the milliseconds between start and end, or -1 if either time
+// is unset.
+func phase(start, end time.Time) float64 {
+	if start.IsZero() || end.IsZero() {
+		return -1
+	}
+	return milliseconds(end.Sub(start))
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+func newHARRequest(req *http.Request, body []byte) harRequest {
+	harReq := harRequest{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		HTTPVersion: req.Proto,
+		Cookies:     []harCookie{},
+		Headers:     harHeaders(req.Header),
+		QueryString: []harNameValue{},
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+	if req.Host != "" {
+		harReq.Headers = append([]harNameValue{{Name: "Host", Value: req.Host}}, harReq.Headers...)
+	}
+	for name, values := range req.URL.Query() {
+		for _, value := range values {
+			harReq.QueryString = append(harReq.QueryString, harNameValue{Name: name, Value: value})
+		}
+	}
+	if len(body) > 0 {
+		text, encoding := harText(body)
+		harReq.PostData = &harPostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     text,
+		}
+		if encoding != "" {
+			harReq.PostData.Comment = encoding + " encoded"
+		}
+	}
+	return harReq
+}
+
+func newHARResponse(resp *http.Response, body []byte) harResponse {
+	text, encoding := harText(body)
+	harResp := harResponse{
+		Status:      resp.StatusCode,
+		StatusText:  strings.TrimSpace(strings.TrimPrefix(resp.Status, strconv.Itoa(resp.StatusCode))),
+		HTTPVersion: resp.Proto,
+		Cookies:     []harCookie{},
+		Headers:     harHeaders(resp.Header),
+		Content: harContent{
+			Size:     len(body),
+			MimeType: resp.Header.Get("Content-Type"),
+			Text:     text,
+			Encoding: encoding,
+		},
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+	if resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		harResp.RedirectURL = resp.Header.Get("Location")
+	}
+	return harResp
+}
+
+// harHeaders returns the given headers as HAR name/value pairs sorted by name.
+func harHeaders(header http.Header) []harNameValue {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	results := []harNameValue{}
+	for _, name := range names {
+		for _, value := range header[name] {
+			results = append(results, harNameValue{Name: name, Value: value})
+		}
+	}
+	return results
+}
+
+// harText returns the body as HAR text. Bodies that aren't UTF-8 (e.g. DER
+// certificates or OCSP responses) are base64 encoded and "base64" is returned
+// as the encoding.
+func harText(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+// decodeJWS returns the decoded protected header and payload of a flattened
+// JSON JWS request body, or nil if the body isn't a JWS.
+func decodeJWS(contentType string, body []byte) *harJWS {
+	if !strings.HasPrefix(contentType, "application/jose+json") {
+		return nil
+	}
+	var jws struct {
+		Protected string `json:"protected"`
+		Payload   string `json:"payload"`
+	}
+	if err := json.Unmarshal(body, &jws); err != nil {
+		return nil
+	}
+	protected, err := base64.RawURLEncoding.DecodeString(jws.Protected)
+	if err != nil || !json.Valid(protected) {
+		return nil
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(jws.Payload)
+	if err != nil {
+		return nil
+	}
+	decoded := &harJWS{
+		Protected: json.RawMessage(protected),
+	}
+	// POST-as-GET requests have an empty payload.
+	switch {
+	case len(payload) == 0:
+		decoded.Payload = ""
+	case json.Valid(payload):
+		decoded.Payload = json.RawMessage(payload)
+	default:
+		decoded.Payload = string(payload)
+	}
+	return decoded
+}
+
+// The following types are the parts of the HAR 1.2 format written by
+// HARRecorder. Fields prefixed with an underscore are custom fields.
+
+type harDocument struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+	// The decoded JWS of the request body, if the request was a JWS.
+	JWS *harJWS `json:"_jws,omitempty"`
+	// The error that prevented a response from being received, if any.
+	Error string `json:"_error,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harCookie    `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harCookie    `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harCookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+type harJWS struct {
+	Protected json.RawMessage `json:"protected"`
+	Payload   any             `json:"payload"`
+}
@@ -4,6 +4,7 @@ package shell
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"os"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/abiosoft/readline"
 	acmeclient "github.com/cpu/acmeshell/acme/client"
 	acmecmd "github.com/cpu/acmeshell/cmd"
+	acmenet "github.com/cpu/acmeshell/net"
 	"github.com/cpu/acmeshell/shell/commands"
 	"github.com/letsencrypt/challtestsrv"
 
@@ -20,10 +22,12 @@ import (
 	// Import new commands here:
 	_ "github.com/cpu/acmeshell/shell/commands/accounts"
 	_ "github.com/cpu/acmeshell/shell/commands/b64url"
+	_ "github.com/cpu/acmeshell/shell/commands/certInfo"
 	_ "github.com/cpu/acmeshell/shell/commands/challSrv"
 	_ "github.com/cpu/acmeshell/shell/commands/csr"
 	_ "github.com/cpu/acmeshell/shell/commands/deactivateAccount"
 	_ "github.com/cpu/acmeshell/shell/commands/deactivateAuthz"
+	_ "github.com/cpu/acmeshell/shell/commands/directory"
 	_ "github.com/cpu/acmeshell/shell/commands/echo"
 	_ "github.com/cpu/acmeshell/shell/commands/finalize"
 	_ "github.com/cpu/acmeshell/shell/commands/get"
@@ -32,23 +36,33 @@ import (
 	_ "github.com/cpu/acmeshell/shell/commands/getCert"
 	_ "github.com/cpu/acmeshell/shell/commands/getChall"
 	_ "github.com/cpu/acmeshell/shell/commands/getOrder"
+	_ "github.com/cpu/acmeshell/shell/commands/har"
+	_ "github.com/cpu/acmeshell/shell/commands/issue"
 	_ "github.com/cpu/acmeshell/shell/commands/jwsDecode"
 	_ "github.com/cpu/acmeshell/shell/commands/keyAuth"
 	_ "github.com/cpu/acmeshell/shell/commands/keys"
 	_ "github.com/cpu/acmeshell/shell/commands/loadAccount"
 	_ "github.com/cpu/acmeshell/shell/commands/loadKey"
 	_ "github.com/cpu/acmeshell/shell/commands/newAccount"
+	_ "github.com/cpu/acmeshell/shell/commands/newAuthz"
 	_ "github.com/cpu/acmeshell/shell/commands/newKey"
 	_ "github.com/cpu/acmeshell/shell/commands/newOrder"
 	_ "github.com/cpu/acmeshell/shell/commands/orders"
 	_ "github.com/cpu/acmeshell/shell/commands/poll"
 	_ "github.com/cpu/acmeshell/shell/commands/post"
+	_ "github.com/cpu/acmeshell/shell/commands/profiles"
+	_ "github.com/cpu/acmeshell/shell/commands/recoverAccount"
+	_ "github.com/cpu/acmeshell/shell/commands/renew"
+	_ "github.com/cpu/acmeshell/shell/commands/renewalInfo"
+	_ "github.com/cpu/acmeshell/shell/commands/revocationStatus"
 	_ "github.com/cpu/acmeshell/shell/commands/revokeCert"
 	_ "github.com/cpu/acmeshell/shell/commands/rollover"
 	_ "github.com/cpu/acmeshell/shell/commands/saveAccount"
 	_ "github.com/cpu/acmeshell/shell/commands/sign"
 	_ "github.com/cpu/acmeshell/shell/commands/solve"
 	_ "github.com/cpu/acmeshell/shell/commands/switchAccount"
+	_ "github.com/cpu/acmeshell/shell/commands/updateAccount"
+	_ "github.com/cpu/acmeshell/shell/commands/verifyCert"
 )
 
 // ACMEShellOptions allows specifying options for creating an ACME shell. This includes
@@ -66,6 +80,13 @@ type ACMEShellOptions struct {
 	TLSPort int
 	// Port number the ACME server validates DNS-01 challenges over.
 	DNSPort int
+	// Default output format of commands, commands.TextOutput or
+	// commands.JSONOutput. Empty means commands.TextOutput.
+	Output string
+	// Optional file path for an HTTP Archive (HAR) recording of all HTTP
+	// traffic. If not empty recording starts before the ACME client is created
+	// and the recording is saved to the path when the shell exits.
+	HARPath string
 }
 
 // ACMEShell is an ishell.Shell instance tailored for ACME. At its core an
@@ -88,30 +109,68 @@ func NewACMEShell(opts *ACMEShellOptions) *ACMEShell {
 		Prompt: commands.BasePrompt,
 	})
 
+	if opts.Output == "" {
+		opts.Output = commands.TextOutput
+	}
+	if opts.Output != commands.TextOutput && opts.Output != commands.JSONOutput {
+		acmecmd.FailOnError(
+			fmt.Errorf("unknown output format %q, must be %q or %q",
+				opts.Output, commands.TextOutput, commands.JSONOutput),
+			"Invalid output option")
+	}
+	// Stash the output format in the shell for commands to access
+	shell.Set(commands.OutputFormatKey, opts.Output)
+
+	// In the JSON output format stdout is reserved for command results.
+	challSrvLog := io.Writer(os.Stdout)
+	if opts.Output == commands.JSONOutput {
+		challSrvLog = os.Stderr
+	}
+
+	// Create an HTTP Archive recorder shared by the ACME client and an external
+	// challenge server so that all of their traffic can be recorded.
+	recorder := acmenet.NewHARRecorder()
+	if opts.HARPath != "" {
+		log.Printf("Recording HTTP traffic to %q\n", opts.HARPath)
+		recorder.Start()
+	}
+	// Stash the recorder and the default recording path in the shell for
+	// commands to access
+	shell.Set(commands.HARRecorderKey, recorder)
+	shell.Set(commands.HARPathKey, opts.HARPath)
+
 	var challSrv commands.ChallengeServer
 	if opts.ChallSrv != "" {
 		log.Printf("Using an external pebble-challtestsrv instance at %q\n", opts.ChallSrv)
 		// Configure an external pebble-challtestsrv as the challenge response
 		// server
-		srv, err := commands.NewRemoteChallengeServer(opts.ChallSrv)
+		srv, err := commands.NewRemoteChallengeServer(opts.ChallSrv, recorder)
 		acmecmd.FailOnError(err, "Unable to create remote challenge server")
 		challSrv = srv
 	} else {
 		log.Printf("Creating an internal challtestsrv\n")
 		// Create an internal challenge response server
+		challSrvLogger := log.New(challSrvLog, "challRespSrv: ", log.Ldate|log.Ltime)
 		srv, err := challtestsrv.New(challtestsrv.Config{
-			HTTPOneAddrs:    []string{fmt.Sprintf(":%d", opts.HTTPPort)},
-			TLSALPNOneAddrs: []string{fmt.Sprintf(":%d", opts.TLSPort)},
-			DNSOneAddrs:     []string{fmt.Sprintf(":%d", opts.DNSPort)},
-			Log:             log.New(os.Stdout, "challRespSrv: ", log.Ldate|log.Ltime),
+			HTTPOneAddrs: []string{fmt.Sprintf(":%d", opts.HTTPPort)},
+			DNSOneAddrs:  []string{fmt.Sprintf(":%d", opts.DNSPort)},
+			Log:          challSrvLogger,
 		})
 		acmecmd.FailOnError(err, "Unable to create challenge test server")
-		challSrv = srv
+		// TLS-ALPN-01 is served separately so that IP identifiers get the
+		// challenge certificate RFC 8738 requires.
+		tlsALPNOne, err := newTLSALPNOneServer(fmt.Sprintf(":%d", opts.TLSPort), challSrvLogger)
+		acmecmd.FailOnError(err, "Unable to create TLS-ALPN-01 challenge server")
+		challSrv = newInternalChallengeServer(srv, tlsALPNOne)
 	}
 	// Stash the challenge server in the shell for commands to access
 	shell.Set(commands.ChallSrvKey, challSrv)
+	// Stash a tracker for the challenge responses published on the challenge
+	// server in the shell for commands to access
+	shell.Set(commands.ResponseTrackerKey, commands.NewResponseTracker(challSrv))
 
 	// Create an ACME client
+	opts.ClientConfig.HAR = recorder
 	client, err := acmeclient.NewClient(opts.ClientConfig)
 	acmecmd.FailOnError(err, "Unable to create ACME client")
 
@@ -129,14 +188,39 @@ func NewACMEShell(opts *ACMEShellOptions) *ACMEShell {
 // Run starts the ACMEShell, dropping into an interactive session that blocks
 // on user input until it is time to exit. The ACMEShell's challenge server will
 // be started before starting the shell, and shut down after the shell session
-// ends.
+// ends. While the shell runs, published challenge responses are removed from
+// the challenge server once their authorizations reach a terminal status. If
+// an HTTP Archive path was configured the recording is saved when the shell
+// exits.
 func (shell *ACMEShell) Run() {
 	// Start the challenge server
 	challSrv := commands.GetChallSrv(shell)
 	go challSrv.Run()
 
-	shell.Println("Welcome to ACME Shell")
+	// Start watching the authorizations of published challenge responses
+	responses := commands.GetResponseTracker(shell)
+	stopWatching := responses.Watch(commands.GetClient(shell), commands.DefaultResponseWatchInterval)
+
+	// The banners are skipped in the JSON output format so that every line of
+	// output is a command result.
+	jsonOutput := commands.OutputFormat(shell) == commands.JSONOutput
+	if !jsonOutput {
+		shell.Println("Welcome to ACME Shell")
+	}
 	shell.Shell.Run()
-	shell.Println("Goodbye!")
+	if !jsonOutput {
+		shell.Println("Goodbye!")
+	}
+	stopWatching()
 	challSrv.Shutdown()
+
+	// Save the HTTP Archive recording configured at startup
+	if harPath := commands.GetHARPath(shell); harPath != "" {
+		recorder := commands.GetHARRecorder(shell)
+		if err := recorder.Save(harPath); err != nil {
+			log.Printf("Error saving HTTP Archive to %q: %v\n", harPath, err)
+		} else {
+			log.Printf("Saved %d HTTP Archive entries to %q\n", recorder.Len(), harPath)
+		}
+	}
 }
@@ -0,0 +1,227 @@
+package shell
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cpu/acmeshell/acme"
+	"github.com/cpu/acmeshell/acme/resources"
+	"github.com/cpu/acmeshell/shell/commands"
+	"github.com/letsencrypt/challtestsrv"
+)
+
+// internalChallengeServer adapts a challtestsrv.ChallSrv running inside the
+// shell to the commands.ChallengeServer interface. TLS-ALPN-01 challenges are
+// answered by a tlsALPNOneServer instead of the challtestsrv.ChallSrv so that
+// IP identifiers can be validated.
+type internalChallengeServer struct {
+	*challtestsrv.ChallSrv
+	tlsALPNOne *tlsALPNOneServer
+}
+
+// newInternalChallengeServer returns a commands.ChallengeServer for the given
+// challtestsrv.ChallSrv and tlsALPNOneServer.
+func newInternalChallengeServer(srv *challtestsrv.ChallSrv, tlsALPNOne *tlsALPNOneServer) commands.ChallengeServer {
+	return internalChallengeServer{
+		ChallSrv:   srv,
+		tlsALPNOne: tlsALPNOne,
+	}
+}
+
+// Run starts the challtestsrv.ChallSrv and the tlsALPNOneServer.
+func (srv internalChallengeServer) Run() {
+	srv.ChallSrv.Run()
+	go srv.tlsALPNOne.Run()
+}
+
+// Shutdown stops the challtestsrv.ChallSrv and the tlsALPNOneServer.
+func (srv internalChallengeServer) Shutdown() {
+	srv.ChallSrv.Shutdown()
+	srv.tlsALPNOne.Shutdown()
+}
+
+// AddDNSAccountOneChallenge adds a TXT record with the given value for the
+// given account scoped name. The challtestsrv DNS server looks up TXT records by
+// the fully qualified query name.
+func (srv internalChallengeServer) AddDNSAccountOneChallenge(name string, value string) {
+	srv.AddDNSOneChallenge(strings.TrimSuffix(name, ".")+".", value)
+}
+
+// DeleteDNSAccountOneChallenge removes the TXT records for the given account
+// scoped name.
+func (srv internalChallengeServer) DeleteDNSAccountOneChallenge(name string) {
+	srv.DeleteDNSOneChallenge(strings.TrimSuffix(name, ".") + ".")
+}
+
+// AddTLSALPNChallenge adds a TLS-ALPN-01 key authorization for the given
+// domain name or IP address.
+func (srv internalChallengeServer) AddTLSALPNChallenge(host string, keyAuth string) {
+	srv.tlsALPNOne.Add(host, keyAuth)
+}
+
+// DeleteTLSALPNChallenge removes the TLS-ALPN-01 key authorization for the
+// given domain name or IP address.
+func (srv internalChallengeServer) DeleteTLSALPNChallenge(host string) {
+	srv.tlsALPNOne.Delete(host)
+}
+
+// tlsALPNOneResponse is a TLS-ALPN-01 key authorization served by
+// a tlsALPNOneServer.
+type tlsALPNOneResponse struct {
+	// The identifier the challenge certificate is created for.
+	identifier resources.Identifier
+	// The key authorization embedded in the challenge certificate.
+	keyAuth string
+}
+
+// tlsALPNOneServer answers TLS-ALPN-01 challenges for DNS and IP identifiers.
+// The challtestsrv.ChallSrv only creates challenge certificates with a dNSName
+// SAN for the TLS SNI name. For an IP identifier the SNI name is the reverse
+// DNS name of the address and RFC 8738 requires the challenge certificate to
+// have an iPAddress SAN for the address instead.
+//
+// See https://tools.ietf.org/html/rfc8737 and
+// https://tools.ietf.org/html/rfc8738#section-6
+type tlsALPNOneServer struct {
+	srv *http.Server
+	key *ecdsa.PrivateKey
+	log *log.Logger
+
+	mu sync.RWMutex
+	// The responses keyed by the TLS SNI name they are served for.
+	responses map[string]tlsALPNOneResponse
+}
+
+// newTLSALPNOneServer creates a tlsALPNOneServer that listens on the given
+// address once it is Run.
+func newTLSALPNOneServer(address string, logger *log.Logger) (*tlsALPNOneServer, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	s := &tlsALPNOneServer{
+		key:       key,
+		log:       logger,
+		responses: map[string]tlsALPNOneResponse{},
+	}
+	s.srv = &http.Server{
+		Addr:         address,
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 5 * time.Second,
+		TLSConfig: &tls.Config{
+			NextProtos:     []string{challtestsrv.ACMETLS1Protocol},
+			GetCertificate: s.challengeCert,
+		},
+	}
+	s.srv.SetKeepAlivesEnabled(false)
+	return s, nil
+}
+
+// Run listens for TLS-ALPN-01 validation requests until Shutdown is called.
+func (s *tlsALPNOneServer) Run() {
+	// The challenge certificates are created by the TLSConfig GetCertificate
+	// callback so no certificate or key files are needed.
+	err := s.srv.ListenAndServeTLS("", "")
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		s.log.Print(err)
+	}
+}
+
+// Shutdown stops the server.
+func (s *tlsALPNOneServer) Shutdown() {
+	if err := s.srv.Shutdown(context.Background()); err != nil {
+		s.log.Printf("err in Shutdown(): %s\n", err.Error())
+	}
+}
+
+// sniName returns the TLS SNI name used to validate the given identifier.
+func sniName(ident resources.Identifier) string {
+	if ident.Type == acme.IP_IDENTIFIER {
+		return ident.ReverseDNSName()
+	}
+	return strings.ToLower(strings.TrimSuffix(ident.Value, "."))
+}
+
+// Add serves a challenge certificate with the given key authorization for the
+// given domain name or IP address.
+func (s *tlsALPNOneServer) Add(host string, keyAuth string) {
+	ident := resources.NewIdentifier(host)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses[sniName(ident)] = tlsALPNOneResponse{
+		identifier: ident,
+		keyAuth:    keyAuth,
+	}
+}
+
+// Delete stops serving the challenge certificate for the given domain name or
+// IP address.
+func (s *tlsALPNOneServer) Delete(host string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.responses, sniName(resources.NewIdentifier(host)))
+}
+
+// challengeCert returns a self-signed challenge certificate for the response
+// published for the TLS SNI name of the ClientHello.
+func (s *tlsALPNOneServer) challengeCert(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if len(hello.SupportedProtos) != 1 || hello.SupportedProtos[0] != challtestsrv.ACMETLS1Protocol {
+		return nil, fmt.Errorf(
+			"ALPN failed, ClientHelloInfo.SupportedProtos: %s",
+			hello.SupportedProtos)
+	}
+
+	s.mu.RLock()
+	response, found := s.responses[strings.ToLower(hello.ServerName)]
+	s.mu.RUnlock()
+	if !found {
+		return nil, fmt.Errorf("unknown ClientHelloInfo.ServerName: %s", hello.ServerName)
+	}
+	s.log.Printf("Serving TLS-ALPN-01 challenge certificate for %q (SNI %q)\n",
+		response.identifier.Value, hello.ServerName)
+
+	kaHash := sha256.Sum256([]byte(response.keyAuth))
+	extValue, err := asn1.Marshal(kaHash[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed marshalling hash OCTET STRING: %s", err)
+	}
+	certTmpl := x509.Certificate{
+		SerialNumber: big.NewInt(1729),
+		ExtraExtensions: []pkix.Extension{
+			{
+				Id:       challtestsrv.IDPeAcmeIdentifier,
+				Critical: true,
+				Value:    extValue,
+			},
+		},
+	}
+	if response.identifier.Type == acme.IP_IDENTIFIER {
+		certTmpl.IPAddresses = []net.IP{net.ParseIP(response.identifier.Value)}
+	} else {
+		certTmpl.DNSNames = []string{response.identifier.Value}
+	}
+	certBytes, err := x509.CreateCertificate(rand.Reader, &certTmpl, &certTmpl, s.key.Public(), s.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating challenge certificate: %s", err)
+	}
+	return &tls.Certificate{
+		Certificate: [][]byte{certBytes},
+		PrivateKey:  s.key,
+	}, nil
+}
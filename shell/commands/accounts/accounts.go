@@ -43,12 +43,12 @@ func accountsHandler(c *ishell.Context) {
 	accountsFlags.BoolVar(&opts.printID, "showID", true, "Print ACME account IDs")
 	accountsFlags.BoolVar(&opts.printContact, "showContact", true, "Print ACME account contact info")
 
-	if _, err := commands.ParseFlagSetArgs(c.Args, accountsFlags); err != nil {
+	if _, err := commands.ParseFlags(c, accountsFlags); err != nil {
 		return
 	}
 
 	if !opts.printID && !opts.printContact {
-		c.Printf("accounts: -showID and -showContact can not both be false\n")
+		commands.Failf(c, "accounts: -showID and -showContact can not both be false\n")
 		return
 	}
 
@@ -58,11 +58,18 @@ func accountsHandler(c *ishell.Context) {
 		return
 	}
 
+	summaries := make([]accountSummary, 0, len(client.Accounts))
 	for i, acct := range client.Accounts {
 		active := " "
 		if client.ActiveAccountID() == acct.ID {
 			active = "*"
 		}
+		summaries = append(summaries, accountSummary{
+			Index:   i,
+			ID:      acct.ID,
+			Contact: acct.Contact,
+			Active:  active == "*",
+		})
 
 		c.Printf("%s", active)
 		c.Printf("%3d)", i)
@@ -81,4 +88,14 @@ func accountsHandler(c *ishell.Context) {
 
 		c.Printf("\n")
 	}
+	commands.SetResource(c, summaries)
+}
+
+// accountSummary is the representation of a shell account reported by the
+// accounts command.
+type accountSummary struct {
+	Index   int      `json:"index"`
+	ID      string   `json:"id"`
+	Contact []string `json:"contact,omitempty"`
+	Active  bool     `json:"active"`
 }
@@ -2,6 +2,7 @@ package b64url
 
 import (
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"flag"
 	"strings"
@@ -46,12 +47,12 @@ func b64urlHandler(c *ishell.Context) {
 	b64urlFlags.StringVar(&opts.data, "data", "", "Data to encode/decode")
 	b64urlFlags.BoolVar(&opts.hex, "hex", false, "Output result in hex instead of as a string")
 
-	if _, err := commands.ParseFlagSetArgs(c.Args, b64urlFlags); err != nil {
+	if _, err := commands.ParseFlags(c, b64urlFlags); err != nil {
 		return
 	}
 
 	if err := opts.validate(); err != nil {
-		c.Printf("Invalid options: %s\n", err)
+		commands.Failf(c, "Invalid options: %s\n", err)
 		return
 	}
 
@@ -67,7 +68,7 @@ func b64urlHandler(c *ishell.Context) {
 	if opts.decode {
 		result, err := base64.RawURLEncoding.DecodeString(input)
 		if err != nil {
-			c.Printf("Error decoding input: %v\n", err)
+			commands.Failf(c, "Error decoding input: %v\n", err)
 			return
 		}
 		output = result
@@ -77,6 +78,7 @@ func b64urlHandler(c *ishell.Context) {
 	}
 
 	if opts.hex {
+		commands.SetResource(c, hex.EncodeToString(output))
 		c.Printf("Result:\n")
 		for len(output) > 0 {
 			b := output[0]
@@ -85,6 +87,7 @@ func b64urlHandler(c *ishell.Context) {
 		}
 		c.Printf("\n")
 	} else {
+		commands.SetResource(c, string(output))
 		c.Printf("Result: \n%s\n", string(output))
 	}
 }
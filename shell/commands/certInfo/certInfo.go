@@ -0,0 +1,95 @@
+package certInfo
+
+import (
+	"crypto/x509"
+	"flag"
+	"os"
+
+	"github.com/abiosoft/ishell"
+	acmeclient "github.com/cpu/acmeshell/acme/client"
+	"github.com/cpu/acmeshell/acme/resources"
+	"github.com/cpu/acmeshell/shell/commands"
+)
+
+func init() {
+	commands.RegisterCommand(
+		&ishell.Cmd{
+			Name:     "certInfo",
+			Aliases:  []string{"inspectCert"},
+			Help:     "Show the details of a certificate chain and check it against its order",
+			LongHelp: `TODO(@cpu): Write this!`,
+			Func:     certInfoHandler,
+		},
+		nil)
+}
+
+type certInfoOptions struct {
+	pemPath    string
+	orderIndex int
+	chainIndex int
+}
+
+func certInfoHandler(c *ishell.Context) {
+	opts := certInfoOptions{}
+	certInfoFlags := flag.NewFlagSet("certInfo", flag.ContinueOnError)
+	certInfoFlags.StringVar(&opts.pemPath, "path", "", "file path of a PEM certificate chain to inspect instead of an order's certificate")
+	certInfoFlags.IntVar(&opts.orderIndex, "order", -1, "index of existing order")
+	certInfoFlags.IntVar(&opts.chainIndex, "chain", 0, "index of the order's certificate chain to inspect")
+
+	leftovers, err := commands.ParseFlags(c, certInfoFlags)
+	if err != nil {
+		return
+	}
+
+	client := commands.GetClient(c)
+
+	// A PEM file is only checked against an order if one was explicitly chosen.
+	var order *resources.Order
+	if opts.pemPath == "" || opts.orderIndex != -1 || len(leftovers) > 0 {
+		targetURL, err := commands.FindOrderURL(c, leftovers, opts.orderIndex)
+		if err != nil {
+			commands.Failf(c, "certInfo: error getting order URL: %v\n", err)
+			return
+		}
+		order = &resources.Order{
+			ID: targetURL,
+		}
+		if err := client.UpdateOrder(order); err != nil {
+			commands.Failf(c, "certInfo: error getting order: %v\n", err)
+			return
+		}
+	}
+
+	var certs []*x509.Certificate
+	if opts.pemPath != "" {
+		pemBytes, err := os.ReadFile(opts.pemPath)
+		if err != nil {
+			commands.Failf(c, "certInfo: error reading %q: %v\n", opts.pemPath, err)
+			return
+		}
+		certs, err = acmeclient.ParseCertificates(pemBytes)
+		if err != nil {
+			commands.Failf(c, "certInfo: error parsing certificates from %q: %v\n", opts.pemPath, err)
+			return
+		}
+	} else {
+		chains, err := client.GetCertificateChains(order)
+		if err != nil {
+			commands.Failf(c, "certInfo: error getting certificate chains: %v\n", err)
+			return
+		}
+		if opts.chainIndex < 0 || opts.chainIndex >= len(chains) {
+			commands.Failf(c, "certInfo: -chain index must be 0 <= x < %d\n", len(chains))
+			return
+		}
+		chain := chains[opts.chainIndex]
+		c.Printf("Certificate chain %q\n", chain.URL)
+		certs = chain.Certs
+	}
+
+	commands.PrintCertificateChain(c, client, certs, order)
+	commands.SetResource(c, commands.SummarizeCertificates(certs))
+	if order != nil {
+		commands.SetURL(c, order.ID)
+	}
+}
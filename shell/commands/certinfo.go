@@ -0,0 +1,240 @@
+package commands
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/abiosoft/ishell"
+	acmeclient "github.com/cpu/acmeshell/acme/client"
+	"github.com/cpu/acmeshell/acme/resources"
+)
+
+var keyUsageNames = []struct {
+	usage x509.KeyUsage
+	name  string
+}{
+	{x509.KeyUsageDigitalSignature, "Digital Signature"},
+	{x509.KeyUsageContentCommitment, "Content Commitment"},
+	{x509.KeyUsageKeyEncipherment, "Key Encipherment"},
+	{x509.KeyUsageDataEncipherment, "Data Encipherment"},
+	{x509.KeyUsageKeyAgreement, "Key Agreement"},
+	{x509.KeyUsageCertSign, "Certificate Sign"},
+	{x509.KeyUsageCRLSign, "CRL Sign"},
+	{x509.KeyUsageEncipherOnly, "Encipher Only"},
+	{x509.KeyUsageDecipherOnly, "Decipher Only"},
+}
+
+var extKeyUsageNames = map[x509.ExtKeyUsage]string{
+	x509.ExtKeyUsageAny:             "Any",
+	x509.ExtKeyUsageServerAuth:      "Server Authentication",
+	x509.ExtKeyUsageClientAuth:      "Client Authentication",
+	x509.ExtKeyUsageCodeSigning:     "Code Signing",
+	x509.ExtKeyUsageEmailProtection: "Email Protection",
+	x509.ExtKeyUsageTimeStamping:    "Time Stamping",
+	x509.ExtKeyUsageOCSPSigning:     "OCSP Signing",
+}
+
+// PrintCertificateChain prints a description of each certificate in the given
+// chain. If the order is not nil the chain's leaf certificate is also checked
+// against the order's identifiers and finalize key (see PrintLeafChecks).
+func PrintCertificateChain(
+	c *ishell.Context,
+	client *acmeclient.Client,
+	certs []*x509.Certificate,
+	order *resources.Order) {
+	for i, cert := range certs {
+		role := "intermediate"
+		if i == 0 {
+			role = "leaf"
+		} else if isSelfSigned(cert) {
+			role = "root"
+		}
+		c.Printf("Certificate %d (%s):\n", i, role)
+		PrintCertificate(c, cert)
+	}
+	if len(certs) > 0 {
+		PrintLeafChecks(c, client, certs[0], order)
+	}
+}
+
+// PrintCertificate prints the subject, issuer, serial, validity period, SANs,
+// key type, key usages, AIA and CRL URLs and embedded SCTs of the given
+// certificate.
+func PrintCertificate(c *ishell.Context, cert *x509.Certificate) {
+	c.Printf("  Subject: %s\n", cert.Subject)
+	c.Printf("  Issuer: %s\n", cert.Issuer)
+	c.Printf("  Serial: %x\n", cert.SerialNumber)
+	c.Printf("  Not Before: %s\n", cert.NotBefore.UTC().Format(time.RFC3339))
+	c.Printf("  Not After: %s\n", cert.NotAfter.UTC().Format(time.RFC3339))
+	if sans := certificateSANs(cert); len(sans) > 0 {
+		c.Printf("  SANs: %s\n", strings.Join(sans, ", "))
+	}
+	c.Printf("  Key: %s\n", describePublicKey(cert.PublicKey))
+	if usages := keyUsages(cert.KeyUsage); len(usages) > 0 {
+		c.Printf("  Key Usage: %s\n", strings.Join(usages, ", "))
+	}
+	if usages := extKeyUsages(cert); len(usages) > 0 {
+		c.Printf("  Extended Key Usage: %s\n", strings.Join(usages, ", "))
+	}
+	if len(cert.OCSPServer) > 0 {
+		c.Printf("  OCSP Servers: %s\n", strings.Join(cert.OCSPServer, ", "))
+	}
+	if len(cert.IssuingCertificateURL) > 0 {
+		c.Printf("  Issuing Certificate URLs: %s\n", strings.Join(cert.IssuingCertificateURL, ", "))
+	}
+	if len(cert.CRLDistributionPoints) > 0 {
+		c.Printf("  CRL Distribution Points: %s\n", strings.Join(cert.CRLDistributionPoints, ", "))
+	}
+
+	scts, err := acmeclient.EmbeddedSCTs(cert)
+	if err != nil {
+		c.Printf("  SCTs: error parsing embedded SCTs: %v\n", err)
+	} else if len(scts) > 0 {
+		c.Printf("  SCTs:\n")
+		for _, sct := range scts {
+			c.Printf("    v%d log %s at %s\n",
+				sct.Version+1,
+				base64.StdEncoding.EncodeToString(sct.LogID),
+				sct.Timestamp.Format(time.RFC3339))
+		}
+	}
+}
+
+// PrintLeafChecks prints the result of checking the given leaf certificate
+// against the given order. The certificate SANs are compared with the order
+// identifiers and the certificate public key is compared with the key the
+// order was finalized with by the finalize or issue commands. Nothing is
+// checked if the order is nil.
+func PrintLeafChecks(
+	c *ishell.Context,
+	client *acmeclient.Client,
+	leaf *x509.Certificate,
+	order *resources.Order) {
+	if order == nil {
+		return
+	}
+	c.Printf("Checks:\n")
+	problems := acmeclient.CheckOrderCertificate(order, leaf)
+	if len(problems) == 0 {
+		c.Printf("  OK: certificate SANs match the identifiers of order %q\n", order.ID)
+	}
+	for _, problem := range problems {
+		c.Printf("  PROBLEM: %v\n", problem)
+	}
+
+	keyID, found := client.ActiveAccount.FinalizeKeyID(order.ID)
+	if !found {
+		c.Printf("  UNKNOWN: unknown finalize key for order %q\n", order.ID)
+		return
+	}
+	key, found := client.Keys[keyID]
+	switch {
+	case !found:
+		c.Printf("  PROBLEM: finalize key ID %q is not in the shell\n", keyID)
+	case acmeclient.PublicKeyMatches(leaf, key):
+		c.Printf("  OK: certificate public key matches finalize key ID %q\n", keyID)
+	default:
+		c.Printf("  PROBLEM: certificate public key does not match finalize key ID %q\n", keyID)
+	}
+}
+
+// CertificateSummary is a description of a certificate reported with
+// SetResource.
+type CertificateSummary struct {
+	Subject   string    `json:"subject"`
+	Issuer    string    `json:"issuer"`
+	Serial    string    `json:"serial"`
+	NotBefore time.Time `json:"notBefore"`
+	NotAfter  time.Time `json:"notAfter"`
+	SANs      []string  `json:"sans,omitempty"`
+	Key       string    `json:"key"`
+	PEM       string    `json:"pem"`
+}
+
+// SummarizeCertificates returns a CertificateSummary for each of the given
+// certificates.
+func SummarizeCertificates(certs []*x509.Certificate) []CertificateSummary {
+	summaries := make([]CertificateSummary, 0, len(certs))
+	for _, cert := range certs {
+		summaries = append(summaries, CertificateSummary{
+			Subject:   cert.Subject.String(),
+			Issuer:    cert.Issuer.String(),
+			Serial:    fmt.Sprintf("%x", cert.SerialNumber),
+			NotBefore: cert.NotBefore.UTC(),
+			NotAfter:  cert.NotAfter.UTC(),
+			SANs:      certificateSANs(cert),
+			Key:       describePublicKey(cert.PublicKey),
+			PEM: string(pem.EncodeToMemory(&pem.Block{
+				Type:  "CERTIFICATE",
+				Bytes: cert.Raw,
+			})),
+		})
+	}
+	return summaries
+}
+
+func isSelfSigned(cert *x509.Certificate) bool {
+	return cert.CheckSignatureFrom(cert) == nil
+}
+
+func certificateSANs(cert *x509.Certificate) []string {
+	var sans []string
+	for _, name := range cert.DNSNames {
+		sans = append(sans, "DNS:"+name)
+	}
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, "IP:"+ip.String())
+	}
+	for _, email := range cert.EmailAddresses {
+		sans = append(sans, "email:"+email)
+	}
+	for _, uri := range cert.URIs {
+		sans = append(sans, "URI:"+uri.String())
+	}
+	return sans
+}
+
+func describePublicKey(pub any) string {
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		return fmt.Sprintf("ECDSA %s", k.Curve.Params().Name)
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA %d", k.N.BitLen())
+	case ed25519.PublicKey:
+		return "Ed25519"
+	default:
+		return fmt.Sprintf("unknown (%T)", pub)
+	}
+}
+
+func keyUsages(usage x509.KeyUsage) []string {
+	var names []string
+	for _, ku := range keyUsageNames {
+		if usage&ku.usage != 0 {
+			names = append(names, ku.name)
+		}
+	}
+	return names
+}
+
+func extKeyUsages(cert *x509.Certificate) []string {
+	var names []string
+	for _, eku := range cert.ExtKeyUsage {
+		name, ok := extKeyUsageNames[eku]
+		if !ok {
+			name = fmt.Sprintf("unknown (%d)", eku)
+		}
+		names = append(names, name)
+	}
+	for _, oid := range cert.UnknownExtKeyUsage {
+		names = append(names, oid.String())
+	}
+	return names
+}
@@ -2,6 +2,7 @@ package challSrv
 
 import (
 	"flag"
+	"time"
 
 	"github.com/abiosoft/ishell"
 	"github.com/cpu/acmeshell/shell/commands"
@@ -16,7 +17,7 @@ func init() {
 		&ishell.Cmd{
 			Name:     "challSrv",
 			Aliases:  []string{"chalSrv", "challengeServer"},
-			Help:     "Add/remove challenge responses from the embedded challenge response server",
+			Help:     "Add/remove/list challenge responses on the challenge response server",
 			LongHelp: longHelp,
 			Func:     challSrvHandler,
 		},
@@ -29,6 +30,8 @@ type challSrvOptions struct {
 	host          string
 	value         string
 	operation     string
+	list          bool
+	clear         bool
 }
 
 func challSrvHandler(c *ishell.Context) {
@@ -36,56 +39,50 @@ func challSrvHandler(c *ishell.Context) {
 	challSrvFlags := flag.NewFlagSet("challSrv", flag.ContinueOnError)
 	challSrvFlags.StringVar(&opts.challengeType, "challengeType", "", "Challenge type to add/remove")
 	challSrvFlags.StringVar(&opts.token, "token", "", "Challenge token (HTTP-01 only)")
-	challSrvFlags.StringVar(&opts.host, "host", "", "Challenge response host (DNS-01/TLS-ALPN-01 only)")
+	challSrvFlags.StringVar(&opts.host, "host", "", "Challenge response host (DNS-01/TLS-ALPN-01 only) or TXT record name (DNS-ACCOUNT-01 only)")
 	challSrvFlags.StringVar(&opts.value, "value", "", "Challenge response value")
-	challSrvFlags.StringVar(&opts.operation, "operation", "add", "'add' to add a challenge, 'del' to remove")
+	challSrvFlags.StringVar(&opts.operation, "operation", "add", "'add' to add a challenge, 'delete' to remove")
+	challSrvFlags.BoolVar(&opts.list, "list", false, "List the challenge responses currently published by the shell")
+	challSrvFlags.BoolVar(&opts.clear, "clear", false, "Remove all challenge responses currently published by the shell")
 
-	if _, err := commands.ParseFlagSetArgs(c.Args, challSrvFlags); err != nil {
+	if _, err := commands.ParseFlags(c, challSrvFlags); err != nil {
+		return
+	}
+
+	responses := commands.GetResponseTracker(c)
+
+	if opts.list && opts.clear {
+		commands.Failf(c, "challSrv: -list and -clear are mutually exclusive\n")
+		return
+	}
+	if opts.list {
+		listResponses(c, responses.List())
+		return
+	}
+	if opts.clear {
+		c.Printf("Removed %d challenge responses\n", responses.Clear())
 		return
 	}
 
 	if opts.operation != "add" && opts.operation != "delete" {
-		c.Printf("challSrv: -operation must be \"add\" or \"delete\"\n")
+		commands.Failf(c, "challSrv: -operation must be \"add\" or \"delete\"\n")
 		return
 	}
 	if opts.challengeType == "http-01" && opts.host != "" {
-		c.Printf("challSrv: -challengeType http-01 does not use a -host argument\n")
+		commands.Failf(c, "challSrv: -challengeType http-01 does not use a -host argument\n")
 		return
 	}
 	if opts.challengeType != "http-01" && opts.token != "" {
-		c.Printf("challSrv: only -challengeType http-01 uses a -token argument\n")
+		commands.Failf(c, "challSrv: only -challengeType http-01 uses a -token argument\n")
 		return
 	}
-	if opts.challengeType != "http-01" && opts.challengeType != "dns-01" && opts.challengeType != "tls-alpn-01" {
-		c.Printf("challSrv: -challengeType must be one of http-01, dns-01 or tls-alpn-01\n")
+	switch opts.challengeType {
+	case "http-01", "dns-01", "dns-account-01", "tls-alpn-01":
+	default:
+		commands.Failf(c, "challSrv: -challengeType must be one of http-01, dns-01, dns-account-01 or tls-alpn-01\n")
 		return
 	}
 
-	challSrv := commands.GetChallSrv(c)
-
-	type challengeAdder func(string, string)
-	type challengeRemover func(string)
-
-	type challengeType struct {
-		adder   challengeAdder
-		remover challengeRemover
-	}
-
-	challengeHandlers := map[string]challengeType{
-		"http-01": {
-			adder:   challSrv.AddHTTPOneChallenge,
-			remover: challSrv.DeleteHTTPOneChallenge,
-		},
-		"dns-01": {
-			adder:   challSrv.AddDNSOneChallenge,
-			remover: challSrv.DeleteDNSOneChallenge,
-		},
-		"tls-alpn-01": {
-			adder:   challSrv.AddTLSALPNChallenge,
-			remover: challSrv.DeleteTLSALPNChallenge,
-		},
-	}
-
 	operation := opts.operation
 	challType := opts.challengeType
 
@@ -93,13 +90,38 @@ func challSrvHandler(c *ishell.Context) {
 	if challType == "http-01" {
 		host = opts.token
 	}
-	value := opts.value
 
 	if operation == "add" {
 		c.Printf("Adding %s challenge response for host %q\n", challType, host)
-		challengeHandlers[challType].adder(host, value)
+		err := responses.Publish(commands.PublishedResponse{
+			ChallengeType: challType,
+			Key:           host,
+			Value:         opts.value,
+		})
+		if err != nil {
+			commands.Failf(c, "challSrv: error adding challenge response: %v\n", err)
+		}
 	} else {
 		c.Printf("Removing %s challenge response for host %q\n", challType, host)
-		challengeHandlers[challType].remover(host)
+		if err := responses.Remove(challType, host); err != nil {
+			commands.Failf(c, "challSrv: error removing challenge response: %v\n", err)
+		}
+	}
+}
+
+func listResponses(c *ishell.Context, responses []commands.PublishedResponse) {
+	commands.SetResource(c, responses)
+	if len(responses) == 0 {
+		c.Printf("No challenge responses are published\n")
+		return
+	}
+	for i, resp := range responses {
+		c.Printf("%3d) %s %q = %q\n", i, resp.ChallengeType, resp.Key, resp.Value)
+		c.Printf("     Published: %s\n", resp.Published.UTC().Format(time.RFC3339))
+		if resp.AuthzURL != "" {
+			c.Printf("     Authz: %q\n", resp.AuthzURL)
+		} else {
+			c.Printf("     Authz: none (added manually)\n")
+		}
 	}
 }
@@ -3,6 +3,9 @@ package commands
 import (
 	"encoding/json"
 	"fmt"
+	"log"
+	"net"
+	"strings"
 
 	acmenet "github.com/cpu/acmeshell/net"
 )
@@ -22,6 +25,12 @@ type ChallengeServer interface {
 	AddDNSOneChallenge(host string, keyAuth string)
 	DeleteDNSOneChallenge(host string)
 
+	// DNS-ACCOUNT-01 challenge add/remove. The name is the full account scoped
+	// TXT record name (see resources.DNSAccountChallengeName) and the value is
+	// the key authorization digest.
+	AddDNSAccountOneChallenge(name string, value string)
+	DeleteDNSAccountOneChallenge(name string)
+
 	// TLS-ALPN-01 challenge add/remove
 	AddTLSALPNChallenge(host string, keyAuth string)
 	DeleteTLSALPNChallenge(host string)
@@ -39,16 +48,27 @@ type ChallengeServer interface {
 	DeleteDNSAAAARecord(host string)
 }
 
+// fqdn returns the given name with a trailing dot.
+func fqdn(name string) string {
+	return strings.TrimSuffix(name, ".") + "."
+}
+
 type remoteChallengeServer struct {
 	address string
 	net     *acmenet.ACMENet
 }
 
-func NewRemoteChallengeServer(addr string) (ChallengeServer, error) {
+// NewRemoteChallengeServer creates a ChallengeServer that manages challenge
+// responses with the API of the pebble-challtestsrv instance at the given
+// address. If the recorder is not nil the API requests are recorded with it.
+func NewRemoteChallengeServer(addr string, recorder *acmenet.HARRecorder) (ChallengeServer, error) {
 	net, err := acmenet.New("")
 	if err != nil {
 		return nil, err
 	}
+	if recorder != nil {
+		net.RecordTo(recorder)
+	}
 	return remoteChallengeServer{
 		address: addr,
 		net:     net,
@@ -114,13 +134,44 @@ func (srv remoteChallengeServer) DeleteDNSOneChallenge(host string) {
 	req := struct {
 		Host string
 	}{
-		Host: host,
+		Host: "_acme-challenge." + host + ".",
+	}
+	r, _ := srv.net.PostRequest(srv.url(path), mustMarshal(req))
+	_, _ = srv.net.Do(r)
+}
+
+func (srv remoteChallengeServer) AddDNSAccountOneChallenge(name string, value string) {
+	path := "set-txt"
+	req := struct {
+		Host  string
+		Value string
+	}{
+		Host:  fqdn(name),
+		Value: value,
+	}
+	r, _ := srv.net.PostRequest(srv.url(path), mustMarshal(req))
+	_, _ = srv.net.Do(r)
+}
+
+func (srv remoteChallengeServer) DeleteDNSAccountOneChallenge(name string) {
+	path := "clear-txt"
+	req := struct {
+		Host string
+	}{
+		Host: fqdn(name),
 	}
 	r, _ := srv.net.PostRequest(srv.url(path), mustMarshal(req))
 	_, _ = srv.net.Do(r)
 }
 
 func (srv remoteChallengeServer) AddTLSALPNChallenge(host string, keyAuth string) {
+	// pebble-challtestsrv only creates challenge certificates with a dNSName SAN
+	// for the TLS SNI name. RFC 8738 requires an iPAddress SAN for IP
+	// identifiers so a CA will reject the challenge certificate.
+	if net.ParseIP(host) != nil {
+		log.Printf("Warning: pebble-challtestsrv can not serve a TLS-ALPN-01 "+
+			"challenge certificate for IP address %q\n", host)
+	}
 	path := "add-tlsalpn01"
 	req := struct {
 		Host    string
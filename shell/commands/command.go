@@ -8,11 +8,13 @@ import (
 	"flag"
 	"fmt"
 	"net/url"
+	"sort"
 	"strings"
 
 	"github.com/abiosoft/ishell"
 	acmeclient "github.com/cpu/acmeshell/acme/client"
 	"github.com/cpu/acmeshell/acme/resources"
+	acmenet "github.com/cpu/acmeshell/net"
 )
 
 const (
@@ -23,6 +25,21 @@ const (
 	// The ishell context key that we store a challenge response server instance
 	// under.
 	ChallSrvKey = "challsrv"
+	// The ishell context key that we store the challenge response tracker
+	// instance under.
+	ResponseTrackerKey = "responsetracker"
+	// The ishell context key that we store the HTTP Archive recorder instance
+	// under.
+	HARRecorderKey = "harrecorder"
+	// The ishell context key that we store the default path for saving the HTTP
+	// Archive recording under.
+	HARPathKey = "harpath"
+	// The ishell context key that we store the default output format (see
+	// OutputFormat) under.
+	OutputFormatKey = "outputformat"
+	// The ishell context key that a command invocation's output layer is stored
+	// under.
+	outputKey = "output"
 )
 
 func OkURL(urlStr string) bool {
@@ -76,6 +93,47 @@ func GetChallSrv(c shellContext) ChallengeServer {
 		ChallSrvKey))
 }
 
+// GetResponseTracker reads a *ResponseTracker from the shellContext or panics.
+func GetResponseTracker(c shellContext) *ResponseTracker {
+	if c.Get(ResponseTrackerKey) == nil {
+		panic(fmt.Sprintf("nil %q value in shellContext", ResponseTrackerKey))
+	}
+
+	rawTracker := c.Get(ResponseTrackerKey)
+	switch t := rawTracker.(type) {
+	case *ResponseTracker:
+		return t
+	}
+
+	panic(fmt.Sprintf(
+		"%q value in shellContext was not a *ResponseTracker",
+		ResponseTrackerKey))
+}
+
+// GetHARRecorder reads a *net.HARRecorder from the shellContext or panics.
+func GetHARRecorder(c shellContext) *acmenet.HARRecorder {
+	if c.Get(HARRecorderKey) == nil {
+		panic(fmt.Sprintf("nil %q value in shellContext", HARRecorderKey))
+	}
+
+	rawRecorder := c.Get(HARRecorderKey)
+	switch r := rawRecorder.(type) {
+	case *acmenet.HARRecorder:
+		return r
+	}
+
+	panic(fmt.Sprintf(
+		"%q value in shellContext was not a *net.HARRecorder",
+		HARRecorderKey))
+}
+
+// GetHARPath returns the default path for saving the HTTP Archive recording
+// from the shellContext. It is empty if no default path was configured.
+func GetHARPath(c shellContext) string {
+	path, _ := c.Get(HARPathKey).(string)
+	return path
+}
+
 func ReadJSON(c *ishell.Context) string {
 	c.SetPrompt(BasePrompt + "JSON > ")
 	defer c.SetPrompt(BasePrompt)
@@ -106,7 +164,9 @@ func AddCommands(shell *ishell.Shell, client *acmeclient.Client) {
 		if cmdReg.Autocompleter != nil {
 			cmdReg.Cmd.Completer = cmdReg.Autocompleter(client)
 		}
-		shell.AddCmd(cmdReg.Cmd)
+		cmd := *cmdReg.Cmd
+		cmd.Func = withOutput(cmdReg.Cmd)
+		shell.AddCmd(&cmd)
 	}
 }
 
@@ -149,12 +209,10 @@ func DirectoryAutocompleter(c *acmeclient.Client) func(args []string) []string {
 		return nil
 	}
 	var keys []string
-	for key := range dir {
-		if key == "meta" {
-			continue
-		}
+	for key := range dir.Endpoints() {
 		keys = append(keys, key)
 	}
+	sort.Strings(keys)
 	return func(args []string) []string {
 		return keys
 	}
@@ -215,6 +273,59 @@ func FindAuthzURL(ctx *ishell.Context, orderURL string, identifier string) (stri
 	return authzURL, nil
 }
 
+// FindStandaloneAuthzURL finds the URL of one of the active account's
+// standalone authorizations created with newAuthz. If authzIndex is not
+// negative the authorization with that index is used. Otherwise if identifier
+// is not empty the first standalone authorization for the identifier is used.
+// Otherwise an authorization is picked interactively.
+func FindStandaloneAuthzURL(ctx *ishell.Context, authzIndex int, identifier string) (string, error) {
+	c := GetClient(ctx)
+	if authzIndex >= 0 {
+		return c.ActiveAccount.AuthzURL(authzIndex)
+	}
+	if identifier == "" {
+		return PickStandaloneAuthzURL(ctx)
+	}
+	for _, authzURL := range c.ActiveAccount.Authzs {
+		authz := &resources.Authorization{
+			ID: authzURL,
+		}
+		if err := c.UpdateAuthz(authz); err != nil {
+			return "", err
+		}
+		if authz.Identifier.Matches(identifier) {
+			return authzURL, nil
+		}
+	}
+	return "", fmt.Errorf("active account has no standalone authz for identifier %q", identifier)
+}
+
+// FindAnyAuthzURL finds an authorization URL from either the active account's
+// orders or its standalone authorizations. If authzIndex is not negative
+// FindStandaloneAuthzURL is used. If orderIndex is not negative the
+// authorization is found from that order with FindAuthzURL. Otherwise an order
+// or the standalone authorizations are picked interactively with
+// PickOrderOrStandaloneURL.
+func FindAnyAuthzURL(ctx *ishell.Context, orderIndex int, authzIndex int, identifier string) (string, error) {
+	if authzIndex >= 0 {
+		return FindStandaloneAuthzURL(ctx, authzIndex, identifier)
+	}
+	var orderURL string
+	var err error
+	if orderIndex >= 0 {
+		orderURL, err = FindOrderURL(ctx, nil, orderIndex)
+	} else {
+		orderURL, err = PickOrderOrStandaloneURL(ctx)
+	}
+	if err != nil {
+		return "", err
+	}
+	if orderURL == "" {
+		return FindStandaloneAuthzURL(ctx, -1, identifier)
+	}
+	return FindAuthzURL(ctx, orderURL, identifier)
+}
+
 func FindChallengeURL(ctx *ishell.Context, authzURL string, challType string) (string, error) {
 	c := GetClient(ctx)
 	authz := &resources.Authorization{
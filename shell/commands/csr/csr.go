@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/abiosoft/ishell"
+	"github.com/cpu/acmeshell/acme/keys"
 	"github.com/cpu/acmeshell/acme/resources"
 	"github.com/cpu/acmeshell/shell/commands"
 )
@@ -24,6 +25,7 @@ type csrOptions struct {
 	rawIdentifiers string
 	commonName     string
 	keyID          string
+	keyType        string
 	pem            bool
 	b64url         bool
 	orderIndex     int
@@ -36,21 +38,22 @@ func csrHandler(c *ishell.Context) {
 	csrFlags.BoolVar(&opts.pem, "pem", false, "Output CSR in PEM format")
 	csrFlags.BoolVar(&opts.b64url, "b64url", true, "Output CSR in base64 URL encoding")
 	csrFlags.StringVar(&opts.keyID, "keyID", "", "Existing key ID to use for CSR (Empty to generate and save new key)")
-	csrFlags.StringVar(&opts.rawIdentifiers, "identifiers", "", "Comma separated list of DNS identifiers")
+	csrFlags.StringVar(&opts.keyType, "keyType", "p256", "Type of key to generate when no -keyID is provided: "+strings.Join(keys.KeySpecs, ", "))
+	csrFlags.StringVar(&opts.rawIdentifiers, "identifiers", "", "Comma separated list of DNS or IP address identifiers")
 	csrFlags.IntVar(&opts.orderIndex, "order", -1, "index of existing order")
 
-	leftovers, err := commands.ParseFlagSetArgs(c.Args, csrFlags)
+	leftovers, err := commands.ParseFlags(c, csrFlags)
 	if err != nil {
 		return
 	}
 
 	if opts.rawIdentifiers != "" && len(leftovers) != 0 {
-		c.Printf("csr: can not specify -identifiers and an order URL\n")
+		commands.Failf(c, "csr: can not specify -identifiers and an order URL\n")
 		return
 	}
 
 	if !opts.pem && !opts.b64url {
-		c.Printf("csr: must set either pem or b64url output to true\n")
+		commands.Failf(c, "csr: must set either pem or b64url output to true\n")
 		return
 	}
 
@@ -60,7 +63,7 @@ func csrHandler(c *ishell.Context) {
 	if opts.rawIdentifiers == "" {
 		orderURL, err := commands.FindOrderURL(c, leftovers, opts.orderIndex)
 		if err != nil {
-			c.Printf("csr: error getting order URL: %v\n", err)
+			commands.Failf(c, "csr: error getting order URL: %v\n", err)
 			return
 		}
 		order := &resources.Order{
@@ -68,7 +71,7 @@ func csrHandler(c *ishell.Context) {
 		}
 		err = client.UpdateOrder(order)
 		if err != nil {
-			c.Printf("csr: error getting order URL: %v\n", err)
+			commands.Failf(c, "csr: error getting order URL: %v\n", err)
 			return
 		}
 		for _, ident := range order.Identifiers {
@@ -78,13 +81,18 @@ func csrHandler(c *ishell.Context) {
 		idents = strings.Split(opts.rawIdentifiers, ",")
 	}
 
-	b64CSR, pemCSR, err := client.CSR(opts.commonName, idents, opts.keyID)
+	b64CSR, pemCSR, err := client.CSRWithKeySpec(opts.commonName, idents, opts.keyID, opts.keyType)
 	if err != nil {
-		c.Printf("csr: error creating CSR for identifiers %v: %s\n",
+		commands.Failf(c, "csr: error creating CSR for identifiers %v: %s\n",
 			idents, err.Error())
 		return
 	}
 
+	commands.SetResource(c, csrResult{
+		Base64URL: string(b64CSR),
+		PEM:       string(pemCSR),
+	})
+
 	if opts.b64url {
 		c.Printf("Base64URL: \n%s\n", b64CSR)
 	}
@@ -93,3 +101,9 @@ func csrHandler(c *ishell.Context) {
 		c.Printf("PEM: \n%s\n", pemCSR)
 	}
 }
+
+// csrResult is the result reported by the csr command.
+type csrResult struct {
+	Base64URL string `json:"base64url"`
+	PEM       string `json:"pem"`
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/abiosoft/ishell"
+	acmeclient "github.com/cpu/acmeshell/acme/client"
 	"github.com/cpu/acmeshell/acme/resources"
 	"github.com/cpu/acmeshell/shell/commands"
 )
@@ -30,7 +31,7 @@ func deactivateAccountHandler(c *ishell.Context) {
 	deactivateAcctFlags := flag.NewFlagSet("deactivateAccount", flag.ContinueOnError)
 	deactivateAcctFlags.IntVar(&opts.accountIndex, "account", -1, "account number to deactivate. Default: active account is deactivated")
 
-	if _, err := commands.ParseFlagSetArgs(c.Args, deactivateAcctFlags); err != nil {
+	if _, err := commands.ParseFlags(c, deactivateAcctFlags); err != nil {
 		return
 	}
 
@@ -39,7 +40,7 @@ func deactivateAccountHandler(c *ishell.Context) {
 	var acct *resources.Account
 	if opts.accountIndex >= 0 {
 		if opts.accountIndex >= len(client.Accounts) {
-			c.Printf("deactivateAccount: provided account index (%d) "+
+			commands.Failf(c, "deactivateAccount: provided account index (%d) "+
 				"is larger than number of accounts (%d)\n",
 				opts.accountIndex, len(client.Accounts))
 			return
@@ -48,35 +49,29 @@ func deactivateAccountHandler(c *ishell.Context) {
 		acct = client.Accounts[opts.accountIndex]
 	} else {
 		if client.ActiveAccountID() == "" {
-			c.Printf("deactivateAccount: no active account to deactivate and no -account arg\n")
+			commands.Failf(c, "deactivateAccount: no active account to deactivate and no -account arg\n")
 			return
 		}
 		acct = client.ActiveAccount
 	}
 
 	if acct == nil {
-		c.Printf("deactivateAccount: selected account was nil\n")
+		commands.Failf(c, "deactivateAccount: selected account was nil\n")
 		return
 	}
 
 	targetURL := acct.ID
 	updateMsg := `{ "status": "deactivated" }`
-	signResult, err := client.Sign(targetURL, []byte(updateMsg), nil)
+	resp, err := client.PostSignedURL(targetURL, []byte(updateMsg), nil)
 	if err != nil {
-		c.Printf("deactivateAccount: failed to sign account update POST body: %v\n", err)
+		commands.Failf(c, "deactivateAccount: failed to POST account %q: %v\n", targetURL, err)
 		return
 	}
-
-	resp, err := client.PostURL(targetURL, signResult.SerializedJWS)
-	if err != nil {
-		c.Printf("deactivateAccount: failed to POST account %q: %v\n", targetURL, err)
-		return
-	}
-	respOb := resp.Response
-	if respOb.StatusCode != http.StatusOK {
-		c.Printf("deactivateAccount: failed to POST %q account. Status code: %d\n", targetURL, respOb.StatusCode)
-		c.Printf("deactivateAccount: response body: %s\n", resp.RespBody)
+	if err := acmeclient.CheckResponse(resp, http.StatusOK); err != nil {
+		commands.Failf(c, "deactivateAccount: failed to deactivate account %q: %v\n", targetURL, err)
 		return
 	}
+	commands.SetResource(c, resp.RespBody)
+	commands.SetURL(c, targetURL)
 	c.Printf("Account %q deactivated\n", targetURL)
 }
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/abiosoft/ishell"
+	acmeclient "github.com/cpu/acmeshell/acme/client"
 	"github.com/cpu/acmeshell/shell/commands"
 )
 
@@ -23,6 +24,7 @@ func init() {
 
 type deactivateAuthzOptions struct {
 	orderIndex int
+	authzIndex int
 	identifier string
 }
 
@@ -30,20 +32,31 @@ func deactivateAuthzHandler(c *ishell.Context) {
 	var opts deactivateAuthzOptions
 	deactivateFlags := flag.NewFlagSet("deactivateAuthz", flag.ContinueOnError)
 	deactivateFlags.IntVar(&opts.orderIndex, "order", -1, "index of existing order")
+	deactivateFlags.IntVar(&opts.authzIndex, "authz", -1, "index of existing standalone authorization (see newAuthz)")
 	deactivateFlags.StringVar(&opts.identifier, "identifier", "", "identifier of authorization")
 
-	leftovers, err := commands.ParseFlagSetArgs(c.Args, deactivateFlags)
+	leftovers, err := commands.ParseFlags(c, deactivateFlags)
 	if err != nil {
 		return
 	}
 
 	if opts.orderIndex != -1 && len(leftovers) > 0 {
-		c.Printf("-order can not be used with an authz URL\n")
+		commands.Failf(c, "-order can not be used with an authz URL\n")
+		return
+	}
+
+	if opts.authzIndex != -1 && len(leftovers) > 0 {
+		commands.Failf(c, "-authz can not be used with an authz URL\n")
+		return
+	}
+
+	if opts.orderIndex != -1 && opts.authzIndex != -1 {
+		commands.Failf(c, "-order can not be used with -authz\n")
 		return
 	}
 
 	if opts.identifier != "" && len(leftovers) > 0 {
-		c.Printf("-identifier can not be used with an authz URL\n")
+		commands.Failf(c, "-identifier can not be used with an authz URL\n")
 		return
 	}
 
@@ -54,40 +67,29 @@ func deactivateAuthzHandler(c *ishell.Context) {
 		templateText := strings.Join(leftovers, " ")
 		targetURL, err = commands.ClientTemplate(client, templateText)
 	} else {
-		targetURL, err = commands.FindOrderURL(c, nil, opts.orderIndex)
-		if err != nil {
-			c.Printf("deactivateAuthz: error getting order URL: %v\n", err)
-			return
-		}
-		targetURL, err = commands.FindAuthzURL(c, targetURL, opts.identifier)
+		targetURL, err = commands.FindAnyAuthzURL(c, opts.orderIndex, opts.authzIndex, opts.identifier)
 	}
 
 	if err != nil {
-		c.Printf("deactivateAuthz: error getting authz URL: %v\n", err)
+		commands.Failf(c, "deactivateAuthz: error getting authz URL: %v\n", err)
 		return
 	}
 	if targetURL == "" {
-		c.Printf("deactivateAuthz: target URL was empty\n")
+		commands.Failf(c, "deactivateAuthz: target URL was empty\n")
 		return
 	}
 
 	updateMsg := `{ "status": "deactivated" }`
-	signResult, err := client.Sign(targetURL, []byte(updateMsg), nil)
-	if err != nil {
-		c.Printf("deactivateAuthz: failed to sign authz update POST body: %v\n", err)
-		return
-	}
-
-	resp, err := client.PostURL(targetURL, signResult.SerializedJWS)
+	resp, err := client.PostSignedURL(targetURL, []byte(updateMsg), nil)
 	if err != nil {
-		c.Printf("deactivateAuthz: failed to POST challenge %q: %v\n", targetURL, err)
+		commands.Failf(c, "deactivateAuthz: failed to POST challenge %q: %v\n", targetURL, err)
 		return
 	}
-	respOb := resp.Response
-	if respOb.StatusCode != http.StatusOK {
-		c.Printf("deactivateAuthz: failed to POST %q authz. Status code: %d\n", targetURL, respOb.StatusCode)
-		c.Printf("deactivateAuthz: response body: %s\n", resp.RespBody)
+	if err := acmeclient.CheckResponse(resp, http.StatusOK); err != nil {
+		commands.Failf(c, "deactivateAuthz: failed to deactivate authz %q: %v\n", targetURL, err)
 		return
 	}
+	commands.SetResource(c, resp.RespBody)
+	commands.SetURL(c, targetURL)
 	c.Printf("Authz %q deactivated\n", targetURL)
 }
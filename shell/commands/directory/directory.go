@@ -0,0 +1,126 @@
+package directory
+
+import (
+	"flag"
+	"sort"
+	"strings"
+
+	"github.com/abiosoft/ishell"
+	"github.com/cpu/acmeshell/acme/resources"
+	"github.com/cpu/acmeshell/shell/commands"
+)
+
+func init() {
+	commands.RegisterCommand(
+		&ishell.Cmd{
+			Name:     "directory",
+			Aliases:  []string{"dir"},
+			Help:     "Show the ACME server's directory endpoints and metadata",
+			LongHelp: `TODO(@cpu): Write this!`,
+			Func:     directoryHandler,
+		},
+		nil)
+}
+
+type directoryOptions struct {
+	refresh  bool
+	validate bool
+}
+
+func directoryHandler(c *ishell.Context) {
+	opts := directoryOptions{}
+	directoryFlags := flag.NewFlagSet("directory", flag.ContinueOnError)
+	directoryFlags.BoolVar(&opts.refresh, "refresh", false, "Fetch the directory from the ACME server again instead of using the cached directory")
+	directoryFlags.BoolVar(&opts.validate, "validate", true, "Check the directory for missing required endpoints and non-HTTPS URLs")
+
+	if _, err := commands.ParseFlags(c, directoryFlags); err != nil {
+		return
+	}
+
+	client := commands.GetClient(c)
+
+	if opts.refresh {
+		if err := client.UpdateDirectory(); err != nil {
+			commands.Failf(c, "directory: error refreshing directory: %v\n", err)
+			return
+		}
+	}
+
+	dir, err := client.Directory()
+	if err != nil {
+		commands.Failf(c, "directory: error getting directory: %v\n", err)
+		return
+	}
+
+	commands.SetResource(c, dir)
+	commands.SetURL(c, client.DirectoryURL.String())
+	c.Printf("Directory %q\n", client.DirectoryURL.String())
+	c.Printf("Endpoints:\n")
+	endpoints := dir.Endpoints()
+	for _, name := range sortedKeys(endpoints) {
+		c.Printf("  %s: %s\n", name, endpoints[name])
+	}
+
+	if dir.Meta != nil {
+		printMeta(c, dir.Meta)
+	}
+
+	// Unknown fields with string values are already printed as endpoints.
+	var otherFields []string
+	for _, name := range sortedKeys(dir.Extra) {
+		if _, isEndpoint := endpoints[name]; !isEndpoint {
+			otherFields = append(otherFields, name)
+		}
+	}
+	if len(otherFields) > 0 {
+		c.Printf("Other fields:\n")
+		for _, name := range otherFields {
+			c.Printf("  %s: %s\n", name, string(dir.Extra[name]))
+		}
+	}
+
+	if opts.validate {
+		problems := dir.Validate()
+		if len(problems) == 0 {
+			c.Printf("Directory is valid\n")
+			return
+		}
+		c.Printf("Directory has %d problem(s):\n", len(problems))
+		for _, problem := range problems {
+			c.Printf("  %v\n", problem)
+		}
+		commands.Failf(c, "directory: directory has %d problem(s)\n", len(problems))
+	}
+}
+
+func printMeta(c *ishell.Context, meta *resources.DirectoryMeta) {
+	c.Printf("Meta:\n")
+	if meta.TermsOfService != "" {
+		c.Printf("  termsOfService: %s\n", meta.TermsOfService)
+	}
+	if meta.Website != "" {
+		c.Printf("  website: %s\n", meta.Website)
+	}
+	if len(meta.CAAIdentities) > 0 {
+		c.Printf("  caaIdentities: %s\n", strings.Join(meta.CAAIdentities, ", "))
+	}
+	c.Printf("  externalAccountRequired: %t\n", meta.ExternalAccountRequired)
+	if len(meta.Profiles) > 0 {
+		c.Printf("  profiles:\n")
+		for _, name := range sortedKeys(meta.Profiles) {
+			c.Printf("    %s: %s\n", name, meta.Profiles[name])
+		}
+	}
+	for _, name := range sortedKeys(meta.Extra) {
+		c.Printf("  %s: %s\n", name, string(meta.Extra[name]))
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"flag"
 	"net/http"
+	"strings"
 
 	"github.com/abiosoft/ishell"
+	acmeclient "github.com/cpu/acmeshell/acme/client"
 	"github.com/cpu/acmeshell/acme/resources"
 	"github.com/cpu/acmeshell/shell/commands"
 )
@@ -41,18 +43,18 @@ func finalizeHandler(c *ishell.Context) {
 	finalizeFlags.StringVar(&opts.commonName, "cn", "", "subject common name (CN) for generated CSR")
 	finalizeFlags.IntVar(&opts.orderIndex, "order", -1, "index of existing order")
 
-	leftovers, err := commands.ParseFlagSetArgs(c.Args, finalizeFlags)
+	leftovers, err := commands.ParseFlags(c, finalizeFlags)
 	if err != nil {
 		return
 	}
 
 	if opts.csr != "" && opts.keyID != "" {
-		c.Printf("finalize: -csr and -keyID are mutually exclusive\n")
+		commands.Failf(c, "finalize: -csr and -keyID are mutually exclusive\n")
 		return
 	}
 
 	if opts.csr != "" && opts.commonName != "" {
-		c.Printf("finalize: -csr and -cn are mutually exclusive\n")
+		commands.Failf(c, "finalize: -csr and -cn are mutually exclusive\n")
 		return
 	}
 
@@ -60,7 +62,7 @@ func finalizeHandler(c *ishell.Context) {
 
 	targetURL, err := commands.FindOrderURL(c, leftovers, opts.orderIndex)
 	if err != nil {
-		c.Printf("finalize: error getting order URL: %v\n", err)
+		commands.Failf(c, "finalize: error getting order URL: %v\n", err)
 		return
 	}
 
@@ -69,10 +71,12 @@ func finalizeHandler(c *ishell.Context) {
 	}
 	err = client.UpdateOrder(order)
 	if err != nil {
-		c.Printf("finalize: error getting order: %s\n", err.Error())
+		commands.Failf(c, "finalize: error getting order: %s\n", err.Error())
 		return
 	}
 
+	// The ID of the key the CSR is created with. It is unknown for a -csr.
+	var keyID string
 	var b64csr string
 	if opts.csr != "" {
 		b64csr = opts.csr
@@ -83,10 +87,16 @@ func finalizeHandler(c *ishell.Context) {
 		}
 		csr, _, err := client.CSR(opts.commonName, names, opts.keyID)
 		if err != nil {
-			c.Printf("finalize: error creating csr: %s\n", err.Error())
+			commands.Failf(c, "finalize: error creating csr: %s\n", err.Error())
 			return
 		}
 		b64csr = string(csr)
+		// Without a -keyID the CSR is created with a new key saved under the
+		// names.
+		keyID = opts.keyID
+		if keyID == "" {
+			keyID = strings.Join(names, ",")
+		}
 	}
 
 	finalizeRequest := struct {
@@ -96,22 +106,17 @@ func finalizeHandler(c *ishell.Context) {
 	}
 	finalizeRequestJSON, _ := json.Marshal(&finalizeRequest)
 
-	signResult, err := client.Sign(order.Finalize, finalizeRequestJSON, nil)
-	if err != nil {
-		c.Printf("finalize: failed to sign finalize POST body: %s\n", err.Error())
-		return
-	}
-
-	resp, err := client.PostURL(order.Finalize, signResult.SerializedJWS)
+	resp, err := client.PostSignedURL(order.Finalize, finalizeRequestJSON, nil)
 	if err != nil {
-		c.Printf("finalize: failed to POST order finalization URL %q: %v\n", order.Finalize, err)
+		commands.Failf(c, "finalize: failed to POST order finalization URL %q: %v\n", order.Finalize, err)
 		return
 	}
-	respOb := resp.Response
-	if respOb.StatusCode != http.StatusOK {
-		c.Printf("finalize: failed to POST order finalization URL %q . Status code: %d\n", order.Finalize, respOb.StatusCode)
-		c.Printf("finalize: response body: %s\n", resp.RespBody)
+	if err := acmeclient.CheckResponse(resp, http.StatusOK); err != nil {
+		commands.Failf(c, "finalize: failed to POST order finalization URL %q: %v\n", order.Finalize, err)
 		return
 	}
+	client.ActiveAccount.SetFinalizeKeyID(order.ID, keyID)
+	commands.SetResource(c, resp.RespBody)
+	commands.SetURL(c, order.ID)
 	c.Printf("order %q finalization requested\n", order.ID)
 }
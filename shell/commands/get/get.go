@@ -1,7 +1,7 @@
 package get
 
 import (
-	"fmt"
+	"flag"
 	"log"
 
 	"github.com/abiosoft/ishell"
@@ -44,24 +44,32 @@ func init() {
 }
 
 func getHandler(c *ishell.Context) {
+	getFlags := flag.NewFlagSet("get", flag.ContinueOnError)
+	leftovers, err := commands.ParseFlags(c, getFlags)
+	if err != nil {
+		return
+	}
+
 	client := commands.GetClient(c)
 
-	targetURL, err := commands.FindURL(client, c.Args)
+	targetURL, err := commands.FindURL(client, leftovers)
 	if err != nil {
-		c.Printf("get: error finding URL: %v\n", err)
+		commands.Failf(c, "get: error finding URL: %v\n", err)
 		return
 	}
 
 	if !commands.OkURL(targetURL) {
-		c.Printf("get: illegal url argument %q\n", targetURL)
+		commands.Failf(c, "get: illegal url argument %q\n", targetURL)
 		return
 	}
 
 	log.Printf("Sending HTTP GET request to URL %q\n", targetURL)
 	resp, err := client.GetURL(targetURL)
 	if err != nil {
-		c.Printf("get: error getting URL: %v\n", err)
+		commands.Failf(c, "get: error getting URL: %v\n", err)
 		return
 	}
-	fmt.Printf("%s\n", resp.RespBody)
+	commands.SetResource(c, resp.RespBody)
+	commands.SetURL(c, targetURL)
+	c.Printf("%s\n", resp.RespBody)
 }
@@ -2,6 +2,7 @@ package getAcct
 
 import (
 	"encoding/json"
+	"flag"
 	"net/http"
 
 	"github.com/abiosoft/ishell"
@@ -23,6 +24,11 @@ func init() {
 }
 
 func getAccountHandler(c *ishell.Context) {
+	getAcctFlags := flag.NewFlagSet("getAccount", flag.ContinueOnError)
+	if _, err := commands.ParseFlags(c, getAcctFlags); err != nil {
+		return
+	}
+
 	client := commands.GetClient(c)
 
 	getAcctReq := struct {
@@ -33,32 +39,26 @@ func getAccountHandler(c *ishell.Context) {
 	reqBody, _ := json.Marshal(&getAcctReq)
 	newAcctURL, ok := client.GetEndpointURL(acme.NEW_ACCOUNT_ENDPOINT)
 	if !ok {
-		c.Printf(
+		commands.Failf(c,
 			"getAccount: ACME server missing %q endpoint in directory\n",
 			acme.NEW_ACCOUNT_ENDPOINT)
 		return
 	}
 
-	signResult, err := client.Sign(newAcctURL, reqBody, &acmeclient.SigningOptions{
+	resp, err := client.PostSignedURL(newAcctURL, reqBody, &acmeclient.SigningOptions{
 		EmbedKey: true,
 	})
 	if err != nil {
-		c.Printf("getAccount: %s\n", err)
-		return
-	}
-
-	resp, err := client.PostURL(newAcctURL, signResult.SerializedJWS)
-	if err != nil {
-		c.Printf("getAccount: failed to POST newAccount: %v\n", err)
+		commands.Failf(c, "getAccount: failed to POST newAccount: %v\n", err)
 		return
 	}
 
-	respOb := resp.Response
-	if respOb.StatusCode != http.StatusOK {
-		c.Printf("getAccount: failed to POST newAccount. Status code: %d\n", respOb.StatusCode)
-		c.Printf("getAccount: response body: %s\n", resp.RespBody)
+	if err := acmeclient.CheckResponse(resp, http.StatusOK); err != nil {
+		commands.Failf(c, "getAccount: failed to POST newAccount: %v\n", err)
 		return
 	}
 
+	commands.SetResource(c, resp.RespBody)
+	commands.SetURL(c, resp.Response.Header.Get("Location"))
 	c.Printf("%s\n", resp.RespBody)
 }
@@ -23,6 +23,7 @@ func init() {
 
 type getAuthzOptions struct {
 	orderIndex int
+	authzIndex int
 	identifier string
 }
 
@@ -30,20 +31,31 @@ func getAuthzHandler(c *ishell.Context) {
 	opts := getAuthzOptions{}
 	getAuthzFlags := flag.NewFlagSet("getAuthz", flag.ContinueOnError)
 	getAuthzFlags.IntVar(&opts.orderIndex, "order", -1, "index of existing order")
+	getAuthzFlags.IntVar(&opts.authzIndex, "authz", -1, "index of existing standalone authorization (see newAuthz)")
 	getAuthzFlags.StringVar(&opts.identifier, "identifier", "", "identifier of authorization")
 
-	leftovers, err := commands.ParseFlagSetArgs(c.Args, getAuthzFlags)
+	leftovers, err := commands.ParseFlags(c, getAuthzFlags)
 	if err != nil {
 		return
 	}
 
 	if opts.orderIndex != -1 && len(leftovers) > 0 {
-		c.Printf("-order can not be used with an authz URL\n")
+		commands.Failf(c, "-order can not be used with an authz URL\n")
+		return
+	}
+
+	if opts.authzIndex != -1 && len(leftovers) > 0 {
+		commands.Failf(c, "-authz can not be used with an authz URL\n")
+		return
+	}
+
+	if opts.orderIndex != -1 && opts.authzIndex != -1 {
+		commands.Failf(c, "-order can not be used with -authz\n")
 		return
 	}
 
 	if opts.identifier != "" && len(leftovers) > 0 {
-		c.Printf("-identifier can not be used with an authz URL\n")
+		commands.Failf(c, "-identifier can not be used with an authz URL\n")
 		return
 	}
 
@@ -54,20 +66,15 @@ func getAuthzHandler(c *ishell.Context) {
 		templateText := strings.Join(leftovers, " ")
 		targetURL, err = commands.ClientTemplate(client, templateText)
 	} else {
-		targetURL, err = commands.FindOrderURL(c, nil, opts.orderIndex)
-		if err != nil {
-			c.Printf("getAuthz: error getting order URL: %v\n", err)
-			return
-		}
-		targetURL, err = commands.FindAuthzURL(c, targetURL, opts.identifier)
+		targetURL, err = commands.FindAnyAuthzURL(c, opts.orderIndex, opts.authzIndex, opts.identifier)
 	}
 
 	if err != nil {
-		c.Printf("getAuthz: error getting authz URL: %v\n", err)
+		commands.Failf(c, "getAuthz: error getting authz URL: %v\n", err)
 		return
 	}
 	if targetURL == "" {
-		c.Printf("getAuthz: target URL was empty\n")
+		commands.Failf(c, "getAuthz: target URL was empty\n")
 		return
 	}
 
@@ -76,13 +83,14 @@ func getAuthzHandler(c *ishell.Context) {
 	}
 	err = client.UpdateAuthz(authz)
 	if err != nil {
-		c.Printf("getAuthz: error getting authz: %s\n", err.Error())
+		commands.Failf(c, "getAuthz: error getting authz: %s\n", err.Error())
 		return
 	}
 
+	commands.SetResource(c, authz)
 	authzStr, err := commands.PrintJSON(authz)
 	if err != nil {
-		c.Printf("getAuthz: error serializing authz: %v\n", err)
+		commands.Failf(c, "getAuthz: error serializing authz: %v\n", err)
 		return
 	}
 	c.Printf("%s\n", authzStr)
@@ -2,12 +2,13 @@ package getCert
 
 import (
 	"flag"
-	"net/http"
+	"fmt"
 	"os"
+	"strings"
 
 	"github.com/abiosoft/ishell"
+	acmeclient "github.com/cpu/acmeshell/acme/client"
 	"github.com/cpu/acmeshell/acme/resources"
-	"github.com/cpu/acmeshell/net"
 	"github.com/cpu/acmeshell/shell/commands"
 )
 
@@ -24,9 +25,12 @@ func init() {
 }
 
 type getCertOptions struct {
-	printPEM   bool
-	pemPath    string
-	orderIndex int
+	printPEM        bool
+	pemPath         string
+	orderIndex      int
+	chainIndex      int
+	preferredIssuer string
+	inspect         bool
 }
 
 func getCertHandler(c *ishell.Context) {
@@ -35,14 +39,22 @@ func getCertHandler(c *ishell.Context) {
 	getCertFlags.BoolVar(&opts.printPEM, "pem", true, "print PEM certificate chain output")
 	getCertFlags.StringVar(&opts.pemPath, "path", "", "file path to save PEM certificate chain output to")
 	getCertFlags.IntVar(&opts.orderIndex, "order", -1, "index of existing order")
+	getCertFlags.IntVar(&opts.chainIndex, "chain", -1, "index of the certificate chain to use (default chain if not specified)")
+	getCertFlags.StringVar(&opts.preferredIssuer, "preferredIssuer", "", "common name of a preferred issuer or root to select a chain by")
+	getCertFlags.BoolVar(&opts.inspect, "inspect", false, "print the details of each certificate in the chain and check the leaf against the order")
 
-	leftovers, err := commands.ParseFlagSetArgs(c.Args, getCertFlags)
+	leftovers, err := commands.ParseFlags(c, getCertFlags)
 	if err != nil {
 		return
 	}
 
-	if !opts.printPEM && opts.pemPath == "" {
-		c.Printf("getCert: one of -pem or -path must be provided\n")
+	if !opts.printPEM && opts.pemPath == "" && !opts.inspect {
+		commands.Failf(c, "getCert: one of -pem, -path or -inspect must be provided\n")
+		return
+	}
+
+	if opts.chainIndex != -1 && opts.preferredIssuer != "" {
+		commands.Failf(c, "getCert: -chain and -preferredIssuer are mutually exclusive\n")
 		return
 	}
 
@@ -50,7 +62,7 @@ func getCertHandler(c *ishell.Context) {
 
 	targetURL, err := commands.FindOrderURL(c, leftovers, opts.orderIndex)
 	if err != nil {
-		c.Printf("getCert: error getting order URL: %v\n", err)
+		commands.Failf(c, "getCert: error getting order URL: %v\n", err)
 		return
 	}
 
@@ -59,47 +71,70 @@ func getCertHandler(c *ishell.Context) {
 	}
 	err = client.UpdateOrder(order)
 	if err != nil {
-		c.Printf("getCert: error getting order: %s\n", err.Error())
+		commands.Failf(c, "getCert: error getting order: %s\n", err.Error())
 		return
 	}
 
-	if order.Status != "valid" {
-		c.Printf("getCert: order %q is status %q, not \"valid\"\n", order.ID, order.Status)
+	chains, err := client.GetCertificateChains(order)
+	if err != nil {
+		commands.Failf(c, "getCert: error getting certificate chains: %v\n", err)
 		return
 	}
 
-	if order.Certificate == "" {
-		c.Printf("getCert: order %q has no Certificate URL\n", order.ID)
-		return
+	for i, chain := range chains {
+		c.Printf("chain %d) %q\n\tissuers: %s\n\troot: %s\n",
+			i, chain.URL, strings.Join(chain.Issuers(), " -> "), chain.Root())
 	}
 
-	var resp *net.NetResponse
-	if client.PostAsGet {
-		resp, err = client.PostAsGetURL(order.Certificate)
-	} else {
-		resp, err = client.GetURL(order.Certificate)
-	}
+	chain, err := selectChain(chains, opts)
 	if err != nil {
-		c.Printf("getCert: failed to GET order certificate URL %q : %v\n", order.Certificate, err)
+		commands.Failf(c, "getCert: %v\n", err)
 		return
 	}
-	respOb := resp.Response
-	if respOb.StatusCode != http.StatusOK {
-		c.Printf("getCert: failed to GET order certificate URL %q . Status code: %d\n", order.Certificate, respOb.StatusCode)
-		c.Printf("getCert: response body: %s\n", resp.RespBody)
-		return
+	if chain != chains[0] {
+		c.Printf("getCert: using alternate chain %q\n", chain.URL)
 	}
+	commands.SetResource(c, commands.SummarizeCertificates(chain.Certs))
+	commands.SetURL(c, chain.URL)
 
 	if opts.printPEM {
-		c.Printf("%s", string(resp.RespBody))
+		c.Printf("%s", string(chain.PEM))
+	}
+
+	if opts.inspect {
+		commands.PrintCertificateChain(c, client, chain.Certs, order)
 	}
 
 	if opts.pemPath != "" {
-		err := os.WriteFile(opts.pemPath, resp.RespBody, os.ModePerm)
+		err := os.WriteFile(opts.pemPath, chain.PEM, os.ModePerm)
 		if err != nil {
-			c.Printf("getCert: error writing pem to %q: %s\n", opts.pemPath, err.Error())
+			commands.Failf(c, "getCert: error writing pem to %q: %s\n", opts.pemPath, err.Error())
 			return
 		}
 		c.Printf("getCert: cert chain saved to %q\n", opts.pemPath)
 	}
 }
+
+// selectChain returns the chain chosen by the -chain or -preferredIssuer
+// options. If neither is set the default chain is returned. An error is
+// returned if the -chain index is out of range or no chain has the
+// -preferredIssuer.
+func selectChain(
+	chains []*acmeclient.CertificateChain,
+	opts getCertOptions) (*acmeclient.CertificateChain, error) {
+	if opts.chainIndex != -1 {
+		if opts.chainIndex < 0 || opts.chainIndex >= len(chains) {
+			return nil, fmt.Errorf("-chain index must be 0 <= x < %d", len(chains))
+		}
+		return chains[opts.chainIndex], nil
+	}
+	if opts.preferredIssuer != "" {
+		for _, chain := range chains {
+			if chain.HasIssuer(opts.preferredIssuer) {
+				return chain, nil
+			}
+		}
+		return nil, fmt.Errorf("no certificate chain has issuer %q", opts.preferredIssuer)
+	}
+	return chains[0], nil
+}
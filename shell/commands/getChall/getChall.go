@@ -23,6 +23,7 @@ func init() {
 
 type getChallOptions struct {
 	orderIndex int
+	authzIndex int
 	identifier string
 	challType  string
 }
@@ -31,10 +32,11 @@ func getChallHandler(c *ishell.Context) {
 	opts := getChallOptions{}
 	getChallFlags := flag.NewFlagSet("getChall", flag.ContinueOnError)
 	getChallFlags.IntVar(&opts.orderIndex, "order", -1, "index of existing order")
+	getChallFlags.IntVar(&opts.authzIndex, "authz", -1, "index of existing standalone authorization (see newAuthz)")
 	getChallFlags.StringVar(&opts.identifier, "identifier", "", "identifier of authorization")
 	getChallFlags.StringVar(&opts.challType, "type", "", "challenge type to get")
 
-	leftovers, err := commands.ParseFlagSetArgs(c.Args, getChallFlags)
+	leftovers, err := commands.ParseFlags(c, getChallFlags)
 	if err != nil {
 		return
 	}
@@ -46,23 +48,18 @@ func getChallHandler(c *ishell.Context) {
 		templateText := strings.Join(leftovers, " ")
 		targetURL, err = commands.ClientTemplate(client, templateText)
 		if err != nil {
-			c.Printf("getChall: error templating order URL: %v\n", err)
+			commands.Failf(c, "getChall: error templating order URL: %v\n", err)
 			return
 		}
 	} else {
-		targetURL, err = commands.FindOrderURL(c, nil, opts.orderIndex)
+		targetURL, err = commands.FindAnyAuthzURL(c, opts.orderIndex, opts.authzIndex, opts.identifier)
 		if err != nil {
-			c.Printf("getChall: error getting order URL: %v\n", err)
-			return
-		}
-		targetURL, err = commands.FindAuthzURL(c, targetURL, opts.identifier)
-		if err != nil {
-			c.Printf("getChall: error getting authz URL: %v\n", err)
+			commands.Failf(c, "getChall: error getting authz URL: %v\n", err)
 			return
 		}
 		targetURL, err = commands.FindChallengeURL(c, targetURL, opts.challType)
 		if err != nil {
-			c.Printf("getChall: error getting challenge URL: %v\n", err)
+			commands.Failf(c, "getChall: error getting challenge URL: %v\n", err)
 			return
 		}
 	}
@@ -72,12 +69,13 @@ func getChallHandler(c *ishell.Context) {
 	}
 	err = client.UpdateChallenge(chall)
 	if err != nil {
-		c.Printf("getChall: error getting authz: %s\n", err.Error())
+		commands.Failf(c, "getChall: error getting authz: %s\n", err.Error())
 		return
 	}
+	commands.SetResource(c, chall)
 	challStr, err := commands.PrintJSON(chall)
 	if err != nil {
-		c.Printf("getChall: error serializing challenge: %v\n", err)
+		commands.Failf(c, "getChall: error serializing challenge: %v\n", err)
 		return
 	}
 	c.Printf("%s\n", challStr)
@@ -30,7 +30,7 @@ func getOrderHandler(c *ishell.Context) {
 	getOrderFlags := flag.NewFlagSet("getOrder", flag.ContinueOnError)
 	getOrderFlags.IntVar(&opts.orderIndex, "order", -1, "index of existing order")
 
-	leftovers, err := commands.ParseFlagSetArgs(c.Args, getOrderFlags)
+	leftovers, err := commands.ParseFlags(c, getOrderFlags)
 	if err != nil {
 		return
 	}
@@ -45,7 +45,7 @@ func getOrderHandler(c *ishell.Context) {
 		targetURL, err = commands.FindOrderURL(c, nil, opts.orderIndex)
 	}
 	if err != nil {
-		c.Printf("getAuthz: error getting order URL: %v\n", err)
+		commands.Failf(c, "getAuthz: error getting order URL: %v\n", err)
 		return
 	}
 	order := &resources.Order{
@@ -53,13 +53,14 @@ func getOrderHandler(c *ishell.Context) {
 	}
 	err = client.UpdateOrder(order)
 	if err != nil {
-		c.Printf("getOrder: error getting order: %v\n", err)
+		commands.Failf(c, "getOrder: error getting order: %v\n", err)
 		return
 	}
 
+	commands.SetResource(c, order)
 	orderStr, err := commands.PrintJSON(order)
 	if err != nil {
-		c.Printf("getOrder: error serializing order: %v\n", err)
+		commands.Failf(c, "getOrder: error serializing order: %v\n", err)
 		return
 	}
 	c.Printf("%s\n", orderStr)
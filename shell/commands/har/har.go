@@ -0,0 +1,101 @@
+package har
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/abiosoft/ishell"
+	"github.com/cpu/acmeshell/shell/commands"
+)
+
+const (
+	longHelp = `
+	har:
+	  Show whether HTTP traffic is being recorded and how many requests were recorded.
+
+	har start:
+	  Start a new HTTP Archive (HAR) recording of every HTTP request and response,
+	  discarding any previous recording.
+
+	har stop:
+	  Stop recording. The recorded requests are kept until the next "har start".
+
+	har save [path]:
+	  Save the recorded requests to the given path as a HAR file. The path
+	  defaults to the -har command line flag value.
+	`
+)
+
+func init() {
+	commands.RegisterCommand(
+		&ishell.Cmd{
+			Name:     "har",
+			Aliases:  []string{"httpArchive"},
+			Help:     "Start, stop or save an HTTP Archive (HAR) recording of all HTTP traffic",
+			LongHelp: longHelp,
+			Func:     harHandler,
+		},
+		nil)
+}
+
+// harStatus is the result reported by the har command.
+type harStatus struct {
+	Recording bool   `json:"recording"`
+	Entries   int    `json:"entries"`
+	Path      string `json:"path,omitempty"`
+}
+
+func harHandler(c *ishell.Context) {
+	harFlags := flag.NewFlagSet("har", flag.ContinueOnError)
+	leftovers, err := commands.ParseFlags(c, harFlags)
+	if err != nil {
+		return
+	}
+
+	recorder := commands.GetHARRecorder(c)
+
+	var operation string
+	if len(leftovers) > 0 {
+		operation = strings.ToLower(strings.TrimSpace(leftovers[0]))
+	}
+
+	status := harStatus{}
+	switch operation {
+	case "":
+	case "start":
+		recorder.Start()
+		c.Printf("har: recording started\n")
+	case "stop":
+		recorder.Stop()
+		c.Printf("har: recording stopped after %d requests\n", recorder.Len())
+	case "save":
+		path := commands.GetHARPath(c)
+		if len(leftovers) > 1 {
+			path = strings.TrimSpace(leftovers[1])
+		}
+		if path == "" {
+			commands.Failf(c, "har: no path provided and no -har path configured\n")
+			return
+		}
+		if err := recorder.Save(path); err != nil {
+			commands.Failf(c, "har: error saving HTTP Archive to %q: %v\n", path, err)
+			return
+		}
+		status.Path = path
+		c.Printf("har: saved %d requests to %q\n", recorder.Len(), path)
+	default:
+		commands.Failf(c, "har: unknown operation %q. Use start, stop or save\n", operation)
+		return
+	}
+
+	status.Recording = recorder.Recording()
+	status.Entries = recorder.Len()
+	commands.SetResource(c, status)
+	if operation == "" {
+		state := "not recording"
+		if status.Recording {
+			state = "recording"
+		}
+		c.Printf("har: %s, %d requests recorded\n", state, status.Entries)
+	}
+}
@@ -0,0 +1,501 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abiosoft/ishell"
+	acmeclient "github.com/cpu/acmeshell/acme/client"
+	"github.com/cpu/acmeshell/acme/keys"
+	"github.com/cpu/acmeshell/acme/resources"
+)
+
+// IssueOptions control how Issue obtains a certificate for an order.
+type IssueOptions struct {
+	// The type of challenge to solve for each pending authorization (e.g.
+	// "http-01").
+	ChallengeType string
+	// The ID of an existing shell key to use for the CSR. If empty a new key is
+	// generated with KeyType and stored under the order's identifiers (with
+	// a numeric suffix if a key with that ID already exists).
+	KeyID string
+	// The key specification used to generate a new CSR key (see
+	// keys.KeySpecs).
+	KeyType string
+	// The subject common name for the CSR. If empty the first DNS identifier is
+	// used.
+	CommonName string
+	// The delay before the first re-poll of authorizations and the order.
+	PollInterval time.Duration
+	// The maximum time to wait for each of the authorizations, the order to be
+	// ready, and the order to be valid.
+	Timeout time.Duration
+}
+
+// IssueStep is the outcome of one step of Issue.
+type IssueStep struct {
+	// A short description of the step.
+	Name string
+	// How long the step took.
+	Duration time.Duration
+	// The error that stopped issuance at this step (if any).
+	Err error
+	// The problem document returned by the ACME server for the failure (if
+	// any).
+	Problem *resources.Problem
+}
+
+// IssueResult is the result of Issue. A partial IssueResult is returned when
+// issuance fails.
+type IssueResult struct {
+	// The order created for the certificate.
+	Order *resources.Order
+	// The ID of the shell key used for the CSR.
+	KeyID string
+	// The issued certificate chain. Nil unless issuance completed.
+	Chain *acmeclient.CertificateChain
+	// The steps that were run, in order. The last step failed if issuance did
+	// not complete.
+	Steps []IssueStep
+}
+
+// Failed returns the step that stopped issuance, or nil if issuance completed.
+func (r *IssueResult) Failed() *IssueStep {
+	if len(r.Steps) == 0 {
+		return nil
+	}
+	if last := &r.Steps[len(r.Steps)-1]; last.Err != nil {
+		return last
+	}
+	return nil
+}
+
+// authzResult is the outcome of solving a single authorization.
+type authzResult struct {
+	url      string
+	ident    string
+	status   string
+	duration time.Duration
+	err      error
+}
+
+// Issue runs the full issuance flow for the given order with the active
+// account: the order is created, every pending authorization is solved in
+// parallel with the IssueOptions ChallengeType, the order is finalized once it
+// is ready, and the certificate chain is downloaded once the order is valid.
+// Issue stops at the first step that fails. Progress is printed to the ishell
+// context as each step completes. Use PrintIssueResult to print the summary.
+func Issue(c *ishell.Context, order *resources.Order, opts IssueOptions) *IssueResult {
+	client := GetClient(c)
+	result := &IssueResult{
+		Order: order,
+	}
+
+	// run times the given step function and records it in the result, returning
+	// false if the step failed.
+	run := func(name string, step func() error) bool {
+		start := time.Now()
+		err := step()
+		s := IssueStep{
+			Name:     name,
+			Duration: time.Since(start),
+			Err:      err,
+		}
+		if err != nil {
+			s.Problem = problemFromError(err)
+		}
+		result.Steps = append(result.Steps, s)
+		if err != nil {
+			c.Printf("issue: %s failed after %s: %v\n", name, formatDuration(s.Duration), err)
+			return false
+		}
+		c.Printf("issue: %s done in %s\n", name, formatDuration(s.Duration))
+		return true
+	}
+
+	pollOpts := acmeclient.PollOptions{
+		Interval:    opts.PollInterval,
+		Backoff:     2,
+		MaxInterval: 10 * time.Second,
+		Timeout:     opts.Timeout,
+	}
+
+	ok := run("create order", func() error {
+		if err := client.CreateOrder(order); err != nil {
+			return err
+		}
+		c.Printf("Created order %q\n", order.ID)
+		return nil
+	})
+	if !ok {
+		return result
+	}
+
+	ok = run("solve authorizations", func() error {
+		return solveAuthzs(c, order, opts.ChallengeType, pollOpts)
+	})
+	if !ok {
+		return result
+	}
+
+	ok = run("wait for ready", func() error {
+		return pollStatus(client, order.ID, "ready", pollOpts)
+	})
+	if !ok {
+		return result
+	}
+
+	ok = run("finalize", func() error {
+		keyID, err := finalizeOrder(client, order, opts)
+		result.KeyID = keyID
+		return err
+	})
+	if !ok {
+		return result
+	}
+
+	ok = run("wait for valid", func() error {
+		return pollStatus(client, order.ID, "valid", pollOpts)
+	})
+	if !ok {
+		return result
+	}
+
+	run("download certificate", func() error {
+		if err := client.UpdateOrder(order); err != nil {
+			return err
+		}
+		chains, err := client.GetCertificateChains(order)
+		if err != nil {
+			return err
+		}
+		result.Chain = chains[0]
+		return nil
+	})
+	return result
+}
+
+// solveAuthzs solves every pending authorization of the order in parallel
+// and waits for them to be valid. Authorizations that are already valid are
+// skipped. The results are printed once all authorizations are done and the
+// first failure (in the order's authorization order) is returned.
+func solveAuthzs(
+	c *ishell.Context,
+	order *resources.Order,
+	challType string,
+	pollOpts acmeclient.PollOptions) error {
+	client := GetClient(c)
+	responses := GetResponseTracker(c)
+
+	results := make([]authzResult, len(order.Authorizations))
+	var wg sync.WaitGroup
+	for i, authzURL := range order.Authorizations {
+		wg.Add(1)
+		go func(i int, authzURL string) {
+			defer wg.Done()
+			start := time.Now()
+			status, ident, err := solveAuthz(client, responses, authzURL, challType, pollOpts)
+			results[i] = authzResult{
+				url:      authzURL,
+				ident:    ident,
+				status:   status,
+				duration: time.Since(start),
+				err:      err,
+			}
+		}(i, authzURL)
+	}
+	wg.Wait()
+
+	var firstErr error
+	for _, r := range results {
+		if r.err != nil {
+			c.Printf("  %s: FAILED after %s: %v\n", r.ident, formatDuration(r.duration), r.err)
+			if firstErr == nil {
+				firstErr = &authzError{result: r}
+			}
+			continue
+		}
+		c.Printf("  %s: %s in %s\n", r.ident, r.status, formatDuration(r.duration))
+	}
+	return firstErr
+}
+
+// authzError is the error returned by solveAuthzs for a failed authorization.
+type authzError struct {
+	result authzResult
+}
+
+func (e *authzError) Error() string {
+	return fmt.Sprintf("authz %q for %q: %v", e.result.url, e.result.ident, e.result.err)
+}
+
+func (e *authzError) Unwrap() error {
+	return e.result.err
+}
+
+// solveAuthz publishes the challenge response for the given challenge type of
+// the authorization at authzURL, starts the challenge, and polls the
+// authorization until it is valid. The authorization's final status and
+// identifier are returned.
+func solveAuthz(
+	client *acmeclient.Client,
+	responses *ResponseTracker,
+	authzURL string,
+	challType string,
+	pollOpts acmeclient.PollOptions) (string, string, error) {
+	authz := &resources.Authorization{
+		ID: authzURL,
+	}
+	if err := client.UpdateAuthz(authz); err != nil {
+		return "", authzURL, err
+	}
+	ident := authz.Identifier.Value
+	if authz.Wildcard {
+		ident = "*." + ident
+	}
+	if authz.Status == "valid" {
+		return authz.Status, ident, nil
+	}
+	if authz.Status != "pending" {
+		return authz.Status, ident, fmt.Errorf("authz is %q, not \"pending\"", authz.Status)
+	}
+
+	var chall *resources.Challenge
+	for i := range authz.Challenges {
+		if strings.EqualFold(authz.Challenges[i].Type, challType) {
+			chall = &authz.Challenges[i]
+			break
+		}
+	}
+	if chall == nil {
+		return authz.Status, ident, fmt.Errorf("authz has no %q type challenge", challType)
+	}
+
+	response, err := ChallengeResponse(client, authz, chall)
+	if err != nil {
+		return authz.Status, ident, err
+	}
+	if err := responses.Publish(response); err != nil {
+		return authz.Status, ident, err
+	}
+
+	resp, err := client.PostSignedURL(chall.URL, []byte("{}"), nil)
+	if err != nil {
+		return authz.Status, ident, err
+	}
+	if err := acmeclient.CheckResponse(resp, http.StatusOK); err != nil {
+		return authz.Status, ident, err
+	}
+
+	if err := pollStatus(client, authzURL, "valid", pollOpts); err != nil {
+		return "", ident, err
+	}
+	return "valid", ident, nil
+}
+
+// pollStatus polls the resource at the given URL until it has the given status.
+// If the resource reaches another terminal status its problem document is
+// returned as a *pollProblemError.
+func pollStatus(client *acmeclient.Client, url, status string, pollOpts acmeclient.PollOptions) error {
+	pollOpts.Status = status
+	polled, err := client.Poll(url, pollOpts)
+	if err != nil && polled != nil && polled.Error != nil {
+		return &pollProblemError{err: err, problem: polled.Error}
+	}
+	return err
+}
+
+// pollProblemError is an error from polling a resource that has a problem
+// document.
+type pollProblemError struct {
+	err     error
+	problem *resources.Problem
+}
+
+func (e *pollProblemError) Error() string {
+	return e.err.Error()
+}
+
+func (e *pollProblemError) Unwrap() error {
+	return e.err
+}
+
+// finalizeOrder finalizes the order with a CSR for its identifiers signed by
+// the key selected by the IssueOptions and returns the ID of the key.
+func finalizeOrder(client *acmeclient.Client, order *resources.Order, opts IssueOptions) (string, error) {
+	names := make([]string, len(order.Identifiers))
+	for i, ident := range order.Identifiers {
+		names[i] = ident.Value
+	}
+
+	keyID := opts.KeyID
+	if keyID == "" {
+		keyType := opts.KeyType
+		if keyType == "" {
+			keyType = "p256"
+		}
+		key, err := keys.NewSigner(keyType)
+		if err != nil {
+			return "", err
+		}
+		keyID = unusedKeyID(client, strings.Join(names, ","))
+		client.Keys[keyID] = key
+	}
+
+	csr, _, err := client.CSR(opts.CommonName, names, keyID)
+	if err != nil {
+		return keyID, fmt.Errorf("error creating CSR: %w", err)
+	}
+
+	finalizeRequest := struct {
+		CSR string `json:"csr"`
+	}{
+		CSR: string(csr),
+	}
+	finalizeRequestJSON, _ := json.Marshal(&finalizeRequest)
+
+	resp, err := client.PostSignedURL(order.Finalize, finalizeRequestJSON, nil)
+	if err != nil {
+		return keyID, err
+	}
+	if err := acmeclient.CheckResponse(resp, http.StatusOK); err != nil {
+		return keyID, err
+	}
+	client.ActiveAccount.SetFinalizeKeyID(order.ID, keyID)
+	return keyID, nil
+}
+
+// unusedKeyID returns the given key ID if the client has no key with that ID.
+// Otherwise the ID is returned with the first numeric suffix not in use.
+func unusedKeyID(client *acmeclient.Client, keyID string) string {
+	if _, found := client.Keys[keyID]; !found {
+		return keyID
+	}
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d", keyID, i)
+		if _, found := client.Keys[candidate]; !found {
+			return candidate
+		}
+	}
+}
+
+// problemFromError returns the ACME problem document carried by the error (if
+// any).
+func problemFromError(err error) *resources.Problem {
+	if err == nil {
+		return nil
+	}
+	var pollErr *pollProblemError
+	if errors.As(err, &pollErr) {
+		return pollErr.problem
+	}
+	var probErr *acmeclient.ProblemError
+	if errors.As(err, &probErr) {
+		return &probErr.Problem
+	}
+	return nil
+}
+
+// PrintIssueResult prints a per-step timing summary of the IssueResult and
+// reports the result to the command's output layer. If issuance failed the
+// problem document of the failed step is printed and reported as the command's
+// error.
+func PrintIssueResult(c *ishell.Context, result *IssueResult) {
+	view := issueResultView{
+		KeyID: result.KeyID,
+		Order: result.Order,
+	}
+	var total time.Duration
+	c.Printf("Issuance summary:\n")
+	for _, step := range result.Steps {
+		outcome := "OK"
+		stepView := issueStepView{
+			Name:       step.Name,
+			DurationMS: step.Duration.Milliseconds(),
+		}
+		if step.Err != nil {
+			outcome = "FAILED"
+			stepView.Error = step.Err.Error()
+		}
+		c.Printf("  %-22s %10s  %s\n", step.Name, formatDuration(step.Duration), outcome)
+		total += step.Duration
+		view.Steps = append(view.Steps, stepView)
+	}
+	c.Printf("  %-22s %10s\n", "total", formatDuration(total))
+	if result.Chain != nil {
+		view.Certificate = string(result.Chain.PEM)
+	}
+	SetResource(c, view)
+	if result.Order != nil {
+		SetURL(c, result.Order.ID)
+		SetStatus(c, result.Order.Status)
+	}
+
+	failed := result.Failed()
+	if failed == nil {
+		return
+	}
+	if failed.Problem == nil {
+		Failf(c, "Issuance stopped at %q: %v\n", failed.Name, failed.Err)
+		return
+	}
+	probStr, err := PrintJSON(failed.Problem)
+	if err != nil {
+		Failf(c, "Issuance stopped at %q: %v\n", failed.Name, failed.Err)
+		return
+	}
+	Failf(c, "Issuance stopped at %q with problem:\n%s\n", failed.Name, probStr)
+	if out := getOutput(c); out != nil {
+		out.result.Problem = failed.Problem
+	}
+}
+
+// PrintIssuedCertificate prints the IssueResult with PrintIssueResult. If
+// issuance succeeded the certificate key ID is printed and the certificate
+// chain is written to the pemPath, or printed if the pemPath is empty. The
+// command name prefixes the messages about the PEM file.
+func PrintIssuedCertificate(c *ishell.Context, command string, result *IssueResult, pemPath string) {
+	PrintIssueResult(c, result)
+	if result.Failed() != nil {
+		return
+	}
+
+	c.Printf("Certificate key ID: %q\n", result.KeyID)
+	if pemPath == "" {
+		c.Printf("%s", string(result.Chain.PEM))
+		return
+	}
+	if err := os.WriteFile(pemPath, result.Chain.PEM, os.ModePerm); err != nil {
+		Failf(c, "%s: error writing pem to %q: %v\n", command, pemPath, err)
+		return
+	}
+	c.Printf("%s: cert chain saved to %q\n", command, pemPath)
+}
+
+// issueResultView is the representation of an IssueResult reported by
+// PrintIssueResult.
+type issueResultView struct {
+	Order       *resources.Order `json:"order,omitempty"`
+	KeyID       string           `json:"keyID,omitempty"`
+	Steps       []issueStepView  `json:"steps"`
+	Certificate string           `json:"certificate,omitempty"`
+}
+
+// issueStepView is the representation of an IssueStep reported by
+// PrintIssueResult.
+type issueStepView struct {
+	Name       string `json:"name"`
+	DurationMS int64  `json:"durationMS"`
+	Error      string `json:"error,omitempty"`
+}
+
+// formatDuration rounds the duration to milliseconds for display.
+func formatDuration(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
@@ -0,0 +1,89 @@
+package issue
+
+import (
+	"flag"
+	"strings"
+	"time"
+
+	"github.com/abiosoft/ishell"
+	"github.com/cpu/acmeshell/acme/keys"
+	"github.com/cpu/acmeshell/acme/resources"
+	"github.com/cpu/acmeshell/shell/commands"
+)
+
+func init() {
+	commands.RegisterCommand(
+		&ishell.Cmd{
+			Name:     "issue",
+			Aliases:  []string{"issueCert", "issueCertificate"},
+			Help:     "Create an order, solve its authorizations, finalize it and download the certificate",
+			LongHelp: `TODO(@cpu): Write this!`,
+			Func:     issueHandler,
+		},
+		nil)
+}
+
+type issueOptions struct {
+	rawIdentifiers string
+	challType      string
+	keyID          string
+	keyType        string
+	commonName     string
+	pemPath        string
+	profile        string
+	sleepSeconds   int
+	timeout        time.Duration
+}
+
+func issueHandler(c *ishell.Context) {
+	opts := issueOptions{}
+	issueFlags := flag.NewFlagSet("issue", flag.ContinueOnError)
+	issueFlags.StringVar(&opts.rawIdentifiers, "identifiers", "", "Comma separated list of DNS or IP address identifiers")
+	issueFlags.StringVar(&opts.challType, "challengeType", "http-01", "Challenge type to solve for each authorization")
+	issueFlags.StringVar(&opts.keyID, "keyID", "", "keyID of an existing key to use for the CSR (default: generate a new key)")
+	issueFlags.StringVar(&opts.keyType, "keyType", "p256", "Type of key to generate when no -keyID is provided: "+strings.Join(keys.KeySpecs, ", "))
+	issueFlags.StringVar(&opts.commonName, "cn", "", "subject common name (CN) for the CSR")
+	issueFlags.StringVar(&opts.pemPath, "path", "", "file path to save the PEM certificate chain to (default: print the chain)")
+	issueFlags.StringVar(&opts.profile, "profile", "", "Name of a certificate profile advertised by the server (see profiles)")
+	issueFlags.IntVar(&opts.sleepSeconds, "sleep", 1, "Number of seconds to sleep between the first poll attempts")
+	issueFlags.DurationVar(&opts.timeout, "timeout", 2*time.Minute, "Maximum time to wait for the authorizations, and for the order to be ready and valid")
+
+	if _, err := commands.ParseFlags(c, issueFlags); err != nil {
+		return
+	}
+
+	var idents []resources.Identifier
+	for _, val := range strings.Split(opts.rawIdentifiers, ",") {
+		val = strings.TrimSpace(val)
+		if val == "" {
+			continue
+		}
+		idents = append(idents, resources.NewIdentifier(val))
+	}
+	if len(idents) == 0 {
+		commands.Failf(c, "issue: -identifiers must not be empty\n")
+		return
+	}
+
+	client := commands.GetClient(c)
+	if opts.keyID != "" {
+		if _, found := client.Keys[opts.keyID]; !found {
+			commands.Failf(c, "issue: no key with ID %q exists in shell\n", opts.keyID)
+			return
+		}
+	}
+
+	order := &resources.Order{
+		Identifiers: idents,
+		Profile:     opts.profile,
+	}
+	result := commands.Issue(c, order, commands.IssueOptions{
+		ChallengeType: opts.challType,
+		KeyID:         opts.keyID,
+		KeyType:       opts.keyType,
+		CommonName:    opts.commonName,
+		PollInterval:  time.Duration(opts.sleepSeconds) * time.Second,
+		Timeout:       opts.timeout,
+	})
+	commands.PrintIssuedCertificate(c, "issue", result, opts.pemPath)
+}
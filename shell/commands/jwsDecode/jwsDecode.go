@@ -31,7 +31,7 @@ func jwsDecodeHandler(c *ishell.Context) {
 	opts := jwsDecodeOptions{}
 	jwsDecodeFlags := flag.NewFlagSet("jwsDecode", flag.ContinueOnError)
 
-	if _, err := commands.ParseFlagSetArgs(c.Args, jwsDecodeFlags); err != nil {
+	if _, err := commands.ParseFlags(c, jwsDecodeFlags); err != nil {
 		return
 	}
 
@@ -49,33 +49,45 @@ func jwsDecodeHandler(c *ishell.Context) {
 	}
 	err := json.Unmarshal([]byte(input), &jws)
 	if err != nil {
-		c.Printf("error unmarshaling input JWS: %q\n", err)
+		commands.Failf(c, "error unmarshaling input JWS: %q\n", err)
 		return
 	}
 
 	decodedPayload, err := decode(jws.Payload, false)
 	if err != nil {
-		c.Printf("error decoding input JWS payload field %q: %q\n", jws.Payload, err)
+		commands.Failf(c, "error decoding input JWS payload field %q: %q\n", jws.Payload, err)
 		return
 	}
 
 	decodedProtected, err := decode(jws.Protected, false)
 	if err != nil {
-		c.Printf("error decoding input JWS protected field %q: %q\n", jws.Protected, err)
+		commands.Failf(c, "error decoding input JWS protected field %q: %q\n", jws.Protected, err)
 		return
 	}
 
 	decodedSignature, err := decode(jws.Signature, true)
 	if err != nil {
-		c.Printf("error decoding input JWS signature field %q: %q\n", jws.Signature, err)
+		commands.Failf(c, "error decoding input JWS signature field %q: %q\n", jws.Signature, err)
 		return
 	}
 
+	commands.SetResource(c, decodedJWS{
+		Payload:   decodedPayload,
+		Protected: decodedProtected,
+		Signature: decodedSignature,
+	})
 	c.Printf("Payload: %s\n", decodedPayload)
 	c.Printf("Protected: %s\n", decodedProtected)
 	c.Printf("Signature: %s\n", decodedSignature)
 }
 
+// decodedJWS is the result reported by the jwsDecode command.
+type decodedJWS struct {
+	Payload   string `json:"payload"`
+	Protected string `json:"protected"`
+	Signature string `json:"signature"`
+}
+
 func readData(c *ishell.Context) string {
 	c.SetPrompt(commands.BasePrompt + "JWS > ")
 	defer c.SetPrompt(commands.BasePrompt)
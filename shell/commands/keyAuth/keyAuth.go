@@ -3,7 +3,6 @@ package keyAuth
 import (
 	"crypto"
 	"flag"
-	"fmt"
 
 	"github.com/abiosoft/ishell"
 	"github.com/cpu/acmeshell/acme/keys"
@@ -40,14 +39,14 @@ func keyAuthHandler(c *ishell.Context) {
 	keyAuthFlags.StringVar(&opts.token, "token", "", "challenge token")
 	keyAuthFlags.StringVar(&opts.keyID, "keyID", "", "Key ID of existing key to use instead of active account key")
 
-	if _, err := commands.ParseFlagSetArgs(c.Args, keyAuthFlags); err != nil {
+	if _, err := commands.ParseFlags(c, keyAuthFlags); err != nil {
 		return
 	}
 
 	client := commands.GetClient(c)
 
 	if opts.token != "" && (opts.orderIndex != -1 || opts.identifier != "" || opts.challType != "") {
-		c.Printf("keyAuth: -token can not be used with -order -identifier or -challType\n")
+		commands.Failf(c, "keyAuth: -token can not be used with -order -identifier or -challType\n")
 		return
 	}
 
@@ -55,24 +54,24 @@ func keyAuthHandler(c *ishell.Context) {
 	if opts.token == "" {
 		targetURL, err := commands.FindOrderURL(c, nil, opts.orderIndex)
 		if err != nil {
-			c.Printf("keyAuth: error getting order URL: %v\n", err)
+			commands.Failf(c, "keyAuth: error getting order URL: %v\n", err)
 			return
 		}
 		targetURL, err = commands.FindAuthzURL(c, targetURL, opts.identifier)
 		if err != nil {
-			c.Printf("keyAuth: error getting authz URL: %v\n", err)
+			commands.Failf(c, "keyAuth: error getting authz URL: %v\n", err)
 			return
 		}
 		targetURL, err = commands.FindChallengeURL(c, targetURL, opts.challType)
 		if err != nil {
-			c.Printf("keyAuth: error getting challenge URL: %v\n", err)
+			commands.Failf(c, "keyAuth: error getting challenge URL: %v\n", err)
 			return
 		}
 		chall := &resources.Challenge{
 			URL: targetURL,
 		}
 		if err = client.UpdateChallenge(chall); err != nil {
-			c.Printf("keyAuth: error getting authz: %s\n", err.Error())
+			commands.Failf(c, "keyAuth: error getting authz: %s\n", err.Error())
 			return
 		}
 		token = chall.Token
@@ -89,17 +88,19 @@ func keyAuthHandler(c *ishell.Context) {
 		if key, found := client.Keys[opts.keyID]; found {
 			k = key
 		} else {
-			c.Printf("keyAuth: no key with ID %q exists in shell\n", opts.keyID)
+			commands.Failf(c, "keyAuth: no key with ID %q exists in shell\n", opts.keyID)
 			return
 		}
 	} else {
 		kID := client.ActiveAccountID()
 		if kID == "" {
-			c.Printf("keyAuth: no active account and no -keyID provided\n")
+			commands.Failf(c, "keyAuth: no active account and no -keyID provided\n")
 			return
 		}
 		k = client.ActiveAccount.Signer
 	}
 
-	fmt.Println(keys.KeyAuth(k, token))
+	keyAuth := keys.KeyAuth(k, token)
+	commands.SetResource(c, keyAuth)
+	c.Println(keyAuth)
 }
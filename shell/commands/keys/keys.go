@@ -31,6 +31,8 @@ type viewKeyOptions struct {
 	hexthumbprint bool
 	b64thumbprint bool
 	pemPath       string
+	pkcs8         bool
+	privateJWK    bool
 }
 
 func keysHandler(c *ishell.Context) {
@@ -41,8 +43,10 @@ func keysHandler(c *ishell.Context) {
 	viewKeyFlags.BoolVar(&opts.b64thumbprint, "b64thumbprint", true, "Display JWK public key thumbprint in base64url encoded form")
 	viewKeyFlags.BoolVar(&opts.hexthumbprint, "hexthumbprint", false, "Display JWK public key thumbprint in hex encoded form")
 	viewKeyFlags.StringVar(&opts.pemPath, "path", "", "Path to write PEM private key to")
+	viewKeyFlags.BoolVar(&opts.pkcs8, "pkcs8", false, "Use PKCS#8 for PEM private key output")
+	viewKeyFlags.BoolVar(&opts.privateJWK, "privateJWK", false, "Display private key in JWK format")
 
-	leftovers, err := commands.ParseFlagSetArgs(c.Args, viewKeyFlags)
+	leftovers, err := commands.ParseFlags(c, viewKeyFlags)
 	if err != nil {
 		return
 	}
@@ -54,6 +58,7 @@ func keysHandler(c *ishell.Context) {
 		return
 	}
 
+	var keyID string
 	var key crypto.Signer
 	if len(leftovers) == 0 {
 		var keysList []string
@@ -72,27 +77,33 @@ func keysHandler(c *ishell.Context) {
 		}
 
 		choice := c.MultiChoice(choiceList, "Which key would you like to view? ")
-		key = client.Keys[keysList[choice]]
+		keyID = keysList[choice]
+		key = client.Keys[keyID]
 	} else {
 		templateText := strings.Join(leftovers, " ")
 		rendered, err := commands.ClientTemplate(client, templateText)
 		if err != nil {
-			c.Printf("viewKey: key ID templating error: %s\n", err.Error())
+			commands.Failf(c, "viewKey: key ID templating error: %s\n", err.Error())
 			return
 		}
 		// Use the templated result as the argument
 		if k, found := client.Keys[rendered]; found {
-			key = k
+			keyID, key = rendered, k
 		}
 		if key == nil {
-			c.Printf("viewKey: no key known to shell with id %q\n", rendered)
+			commands.Failf(c, "viewKey: no key known to shell with id %q\n", rendered)
 			return
 		}
 	}
 
+	commands.SetResource(c, commands.SummarizeKey(keyID, key))
+
 	pemContent, err := keys.SignerToPEM(key)
+	if opts.pkcs8 {
+		pemContent, err = keys.SignerToPKCS8PEM(key)
+	}
 	if err != nil {
-		c.Printf("viewKey: failed to marshal key bytes: %s\n", err.Error())
+		commands.Failf(c, "viewKey: failed to marshal key bytes: %s\n", err.Error())
 		return
 	}
 
@@ -103,7 +114,7 @@ func keysHandler(c *ishell.Context) {
 	if opts.pemPath != "" {
 		err := os.WriteFile(opts.pemPath, []byte(pemContent), os.ModePerm)
 		if err != nil {
-			c.Printf("viewKey: error writing pem to %q: %s\n", opts.pemPath, err.Error())
+			commands.Failf(c, "viewKey: error writing pem to %q: %s\n", opts.pemPath, err.Error())
 			return
 		}
 		c.Printf("PEM encoded private key saved to %q\n", opts.pemPath)
@@ -113,6 +124,15 @@ func keysHandler(c *ishell.Context) {
 		c.Printf("JWK:\n%s\n", keys.JWKJSON(key))
 	}
 
+	if opts.privateJWK {
+		privJWK, err := keys.PrivateJWKJSON(key)
+		if err != nil {
+			commands.Failf(c, "viewKey: failed to marshal private JWK: %v\n", err)
+			return
+		}
+		c.Printf("Private JWK:\n%s\n", privJWK)
+	}
+
 	if opts.hexthumbprint || opts.b64thumbprint {
 		thumbBytes := keys.JWKThumbprintBytes(key)
 		thumbprint := keys.JWKThumbprint(key)
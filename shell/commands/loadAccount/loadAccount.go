@@ -30,13 +30,13 @@ func loadAccountHandler(c *ishell.Context) {
 	loadAccountFlags := flag.NewFlagSet("loadAccount", flag.ContinueOnError)
 	loadAccountFlags.BoolVar(&opts.switchTo, "switch", true, "Switch to the account after loading it")
 
-	leftovers, err := commands.ParseFlagSetArgs(c.Args, loadAccountFlags)
+	leftovers, err := commands.ParseFlags(c, loadAccountFlags)
 	if err != nil {
 		return
 	}
 
 	if len(leftovers) < 1 {
-		c.Printf("loadAccount: you must specify a JSON filepath to load from\n")
+		commands.Failf(c, "loadAccount: you must specify a JSON filepath to load from\n")
 		return
 	}
 
@@ -45,7 +45,7 @@ func loadAccountHandler(c *ishell.Context) {
 
 	acct, err := resources.RestoreAccount(argument)
 	if err != nil {
-		c.Printf("loadAccount: error restoring account from %q : %s\n",
+		commands.Failf(c, "loadAccount: error restoring account from %q : %s\n",
 			argument, err)
 		return
 	}
@@ -53,7 +53,7 @@ func loadAccountHandler(c *ishell.Context) {
 	// TODO(@cpu): Maintain a map of account IDs to avoid this o(n) check
 	for i, existingAcct := range client.Accounts {
 		if acct.ID == existingAcct.ID {
-			c.Printf("loadAccount: %q is already loaded as account # %d\n", argument, i)
+			commands.Failf(c, "loadAccount: %q is already loaded as account # %d\n", argument, i)
 			return
 		}
 	}
@@ -64,6 +64,7 @@ func loadAccountHandler(c *ishell.Context) {
 	c.Printf("Restored account with ID %q (Contact %s)\n",
 		acct.ID, acct.Contact)
 	client.Accounts = append(client.Accounts, acct)
+	commands.SetResource(c, acct)
 
 	if opts.switchTo {
 		// use the new account immediately
@@ -1,7 +1,6 @@
 package loadKey
 
 import (
-	"encoding/pem"
 	"flag"
 	"os"
 	"strings"
@@ -16,7 +15,7 @@ func init() {
 		&ishell.Cmd{
 			Name:     "loadKey",
 			Aliases:  []string{"loadPrivateKey"},
-			Help:     "Load an existing PEM ECDSA private key from disk",
+			Help:     "Load an existing PEM, DER or JWK private key from disk",
 			LongHelp: `TODO(@cpu): Write this!`,
 			Func:     loadKeyHandler,
 		},
@@ -32,13 +31,13 @@ func loadKeyHandler(c *ishell.Context) {
 	loadKeyFlags := flag.NewFlagSet("loadKey", flag.ContinueOnError)
 	loadKeyFlags.StringVar(&opts.id, "id", "", "ID for the key")
 
-	leftovers, err := commands.ParseFlagSetArgs(c.Args, loadKeyFlags)
+	leftovers, err := commands.ParseFlags(c, loadKeyFlags)
 	if err != nil {
 		return
 	}
 
 	if len(leftovers) < 1 {
-		c.Printf("loadKey: you must specify a PEM filepath to load from\n")
+		commands.Failf(c, "loadKey: you must specify a key filepath to load from\n")
 		return
 	}
 
@@ -50,35 +49,23 @@ func loadKeyHandler(c *ishell.Context) {
 	}
 
 	if _, found := client.Keys[opts.id]; found {
-		c.Printf("loadKey: there is already a key loaded under ID %q\n", opts.id)
+		commands.Failf(c, "loadKey: there is already a key loaded under ID %q\n", opts.id)
 		return
 	}
 
-	pemBytes, err := os.ReadFile(argument)
+	keyBytes, err := os.ReadFile(argument)
 	if err != nil {
-		c.Printf("loadKey: error reading key PEM from file %q: %s", argument, err.Error())
+		commands.Failf(c, "loadKey: error reading key from file %q: %s\n", argument, err.Error())
 		return
 	}
 
-	block, _ := pem.Decode(pemBytes)
-
-	var keyType string
-	switch t := strings.ToUpper(block.Type); t {
-	case "EC PRIVATE KEY":
-		keyType = "ecdsa"
-	case "RSA PRIVATE KEY":
-		keyType = "rsa"
-	default:
-		c.Printf("loadKey: unknown PEM block type %q\n", t)
-		return
-	}
-
-	signer, err := keys.UnmarshalSigner(block.Bytes, keyType)
+	signer, err := keys.ParseSigner(keyBytes)
 	if err != nil {
-		c.Printf("loadKey: error loading private key from PEM bytes in %q: %v", argument, err)
+		commands.Failf(c, "loadKey: error loading private key from %q: %v\n", argument, err)
 		return
 	}
 
 	client.Keys[opts.id] = signer
+	commands.SetResource(c, commands.SummarizeKey(opts.id, signer))
 	c.Printf("loadKey: restored key from %q to ID %q\n", argument, opts.id)
 }
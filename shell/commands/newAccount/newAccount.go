@@ -6,6 +6,8 @@ import (
 	"strings"
 
 	"github.com/abiosoft/ishell"
+	acmeclient "github.com/cpu/acmeshell/acme/client"
+	"github.com/cpu/acmeshell/acme/keys"
 	"github.com/cpu/acmeshell/acme/resources"
 	"github.com/cpu/acmeshell/shell/commands"
 )
@@ -27,6 +29,10 @@ type newAccountOptions struct {
 	switchTo bool
 	jsonPath string
 	keyID    string
+	keyType  string
+	eabKeyID string
+	eabKey   string
+	eabAlg   string
 }
 
 func newAccountHandler(c *ishell.Context) {
@@ -36,8 +42,12 @@ func newAccountHandler(c *ishell.Context) {
 	newAccountFlags.BoolVar(&opts.switchTo, "switch", true, "Switch to the new account after creating it")
 	newAccountFlags.StringVar(&opts.jsonPath, "json", "", "Optional filepath to a JSON save file for the account")
 	newAccountFlags.StringVar(&opts.keyID, "keyID", "", "Key ID for existing key (empty to generate new key)")
+	newAccountFlags.StringVar(&opts.keyType, "keyType", "p256", "Type of key to generate when no -keyID is provided: "+strings.Join(keys.KeySpecs, ", "))
+	newAccountFlags.StringVar(&opts.eabKeyID, "eabKID", "", "External account binding key ID (empty to use the -eabKID startup value)")
+	newAccountFlags.StringVar(&opts.eabKey, "eabKey", "", "Base64url encoded external account binding MAC key")
+	newAccountFlags.StringVar(&opts.eabAlg, "eabAlg", "HS256", "External account binding MAC algorithm (HS256, HS384 or HS512)")
 
-	if _, err := commands.ParseFlagSetArgs(c.Args, newAccountFlags); err != nil {
+	if _, err := commands.ParseFlags(c, newAccountFlags); err != nil {
 		return
 	}
 
@@ -62,24 +72,45 @@ func newAccountHandler(c *ishell.Context) {
 		if key, found := client.Keys[opts.keyID]; found {
 			acctKey = key
 		} else {
-			c.Printf("newAccount: Key ID %q does not exist in shell\n", opts.keyID)
+			commands.Failf(c, "newAccount: Key ID %q does not exist in shell\n", opts.keyID)
 			return
 		}
+	} else {
+		randKey, err := keys.NewSigner(opts.keyType)
+		if err != nil {
+			commands.Failf(c, "newAccount: error generating new account key: %v\n", err)
+			return
+		}
+		acctKey = randKey
 	}
 	acct, err := resources.NewAccount(emails, acctKey)
 	if err != nil {
-		c.Printf("newAccount: error creating new account object: %s\n", err)
+		commands.Failf(c, "newAccount: error creating new account object: %s\n", err)
 		return
 	}
 
+	// use the client's external account binding unless one was specified
+	eab := client.ExternalAccountBinding
+	if opts.eabKeyID != "" || opts.eabKey != "" {
+		if opts.eabKeyID == "" || opts.eabKey == "" {
+			commands.Failf(c, "newAccount: -eabKID and -eabKey must be provided together\n")
+			return
+		}
+		eab = &acmeclient.ExternalAccountBinding{
+			KeyID:     opts.eabKeyID,
+			MACKey:    opts.eabKey,
+			Algorithm: opts.eabAlg,
+		}
+	}
+
 	// create the account with the ACME server
-	err = client.CreateAccount(acct)
+	err = client.CreateAccountWithEAB(acct, eab)
 	if err != nil {
-		c.Printf("newAccount: error creating new account with ACME server: %s\n", err)
+		commands.Failf(c, "newAccount: error creating new account with ACME server: %s\n", err)
 		return
 	}
-	// if opts.keyID was empty then resources.NewAccount got a nil key argument and
-	// generated a new key on the fly. We need to save that key
+	// if opts.keyID was empty then a new key of -keyType was generated on the
+	// fly. We need to save that key
 	if opts.keyID == "" {
 		client.Keys[acct.ID] = acct.Signer
 		c.Printf("Created private key for ID %q\n", acct.ID)
@@ -88,11 +119,12 @@ func newAccountHandler(c *ishell.Context) {
 	c.Printf("Created account with ID %q Contacts %q\n", acct.ID, acct.Contact)
 	// store the account object
 	client.Accounts = append(client.Accounts, acct)
+	commands.SetResource(c, acct)
 
 	if opts.jsonPath != "" {
 		err := resources.SaveAccount(opts.jsonPath, acct)
 		if err != nil {
-			c.Printf("error saving account to %q : %s\n", opts.jsonPath, err)
+			commands.Failf(c, "error saving account to %q : %s\n", opts.jsonPath, err)
 			return
 		}
 		c.Printf("Saved account data to %q\n", opts.jsonPath)
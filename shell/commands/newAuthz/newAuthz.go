@@ -0,0 +1,63 @@
+package newAuthz
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/abiosoft/ishell"
+	"github.com/cpu/acmeshell/acme/resources"
+	"github.com/cpu/acmeshell/shell/commands"
+)
+
+func init() {
+	commands.RegisterCommand(
+		&ishell.Cmd{
+			Name:     "newAuthz",
+			Aliases:  []string{"newAuthorization", "preAuthz"},
+			Help:     "Create a new standalone ACME authorization (pre-authorization)",
+			LongHelp: `TODO(@cpu): Write this!`,
+			Func:     newAuthzHandler,
+		},
+		nil)
+}
+
+type newAuthzOptions struct {
+	identifier string
+}
+
+func newAuthzHandler(c *ishell.Context) {
+	opts := newAuthzOptions{}
+	newAuthzFlags := flag.NewFlagSet("newAuthz", flag.ContinueOnError)
+	newAuthzFlags.StringVar(&opts.identifier, "identifier", "", "DNS or IP address identifier to pre-authorize")
+
+	leftovers, err := commands.ParseFlags(c, newAuthzFlags)
+	if err != nil {
+		return
+	}
+
+	if opts.identifier == "" && len(leftovers) > 0 {
+		opts.identifier = leftovers[0]
+	}
+	opts.identifier = strings.TrimSpace(opts.identifier)
+	if opts.identifier == "" {
+		commands.Failf(c, "newAuthz: an -identifier is required\n")
+		return
+	}
+
+	client := commands.GetClient(c)
+
+	authz, err := client.CreateAuthz(resources.NewIdentifier(opts.identifier))
+	if err != nil {
+		commands.Failf(c, "newAuthz: error creating new authz with ACME server: %v\n", err)
+		return
+	}
+
+	commands.SetResource(c, authz)
+	authzStr, err := commands.PrintJSON(authz)
+	if err != nil {
+		commands.Failf(c, "newAuthz: error serializing authz: %v\n", err)
+		return
+	}
+	c.Printf("%s\n", authzStr)
+	c.Printf("Created standalone authz index %d\n", len(client.ActiveAccount.Authzs)-1)
+}
@@ -3,6 +3,7 @@ package newKey
 import (
 	"flag"
 	"os"
+	"strings"
 
 	"github.com/abiosoft/ishell"
 	"github.com/cpu/acmeshell/acme/keys"
@@ -22,11 +23,13 @@ func init() {
 }
 
 type newKeyOptions struct {
-	keyID    string
-	printPEM bool
-	printJWK bool
-	pemPath  string
-	keyType  string
+	keyID      string
+	printPEM   bool
+	printJWK   bool
+	pemPath    string
+	keyType    string
+	pkcs8      bool
+	privateJWK bool
 }
 
 func newKeyHandler(c *ishell.Context) {
@@ -36,52 +39,53 @@ func newKeyHandler(c *ishell.Context) {
 	newKeyFlags.BoolVar(&opts.printPEM, "pem", false, "Print PEM output")
 	newKeyFlags.BoolVar(&opts.printJWK, "jwk", true, "Print JWK output")
 	newKeyFlags.StringVar(&opts.pemPath, "path", "", "Path to write PEM private key to")
-	newKeyFlags.StringVar(&opts.keyType, "type", "ecdsa", "Type of key to generate rsa or ecdsa")
+	newKeyFlags.BoolVar(&opts.pkcs8, "pkcs8", false, "Use PKCS#8 for PEM private key output")
+	newKeyFlags.BoolVar(&opts.privateJWK, "privateJWK", false, "Print private JWK output")
+	newKeyFlags.StringVar(&opts.keyType, "type", "p256", "Type of key to generate: "+strings.Join(keys.KeySpecs, ", "))
 
-	if _, err := commands.ParseFlagSetArgs(c.Args, newKeyFlags); err != nil {
+	if _, err := commands.ParseFlags(c, newKeyFlags); err != nil {
 		return
 	}
 
 	if opts.keyID == "" {
-		c.Printf("newKey: -id must not be empty\n")
+		commands.Failf(c, "newKey: -id must not be empty\n")
 		return
 	}
 
-	if !opts.printPEM && !opts.printJWK {
-		c.Printf("newKey: one of -pem or -jwk must be true\n")
-		return
-	}
-
-	if opts.keyType != "ecdsa" && opts.keyType != "rsa" {
-		c.Printf("newKey: -type must be rsa or ecdsa not %q\n", opts.keyType)
+	if !opts.printPEM && !opts.printJWK && !opts.privateJWK {
+		commands.Failf(c, "newKey: one of -pem, -jwk or -privateJWK must be true\n")
 		return
 	}
 
 	client := commands.GetClient(c)
 
 	if _, found := client.Keys[opts.keyID]; found {
-		c.Printf("newKey: there is already a key with ID %q\n", opts.keyID)
+		commands.Failf(c, "newKey: there is already a key with ID %q\n", opts.keyID)
 		return
 	}
 
 	randKey, err := keys.NewSigner(opts.keyType)
 	if err != nil {
-		c.Printf("newKey: error generating new key: %s\n", err.Error())
+		commands.Failf(c, "newKey: error generating new key: %s\n", err.Error())
 		return
 	}
 
 	client.Keys[opts.keyID] = randKey
+	commands.SetResource(c, commands.SummarizeKey(opts.keyID, randKey))
 
 	keyPem, err := keys.SignerToPEM(randKey)
+	if opts.pkcs8 {
+		keyPem, err = keys.SignerToPKCS8PEM(randKey)
+	}
 	if err != nil {
-		c.Printf("newKey: error marshaling key to PEM: %v\n", err)
+		commands.Failf(c, "newKey: error marshaling key to PEM: %v\n", err)
 		return
 	}
 
 	if opts.pemPath != "" {
 		err := os.WriteFile(opts.pemPath, []byte(keyPem), os.ModePerm)
 		if err != nil {
-			c.Printf("newKey: error writing pem to %q: %s\n", opts.pemPath, err.Error())
+			commands.Failf(c, "newKey: error writing pem to %q: %s\n", opts.pemPath, err.Error())
 			return
 		}
 		c.Printf("PEM encoded private key saved to %q\n", opts.pemPath)
@@ -94,4 +98,13 @@ func newKeyHandler(c *ishell.Context) {
 	if opts.printJWK {
 		c.Printf("JWK:\n%s\n", keys.JWKJSON(randKey))
 	}
+
+	if opts.privateJWK {
+		privJWK, err := keys.PrivateJWKJSON(randKey)
+		if err != nil {
+			commands.Failf(c, "newKey: error marshaling private JWK: %v\n", err)
+			return
+		}
+		c.Printf("Private JWK:\n%s\n", privJWK)
+	}
 }
@@ -2,9 +2,12 @@ package newOrder
 
 import (
 	"flag"
+	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/abiosoft/ishell"
+	acmeclient "github.com/cpu/acmeshell/acme/client"
 	"github.com/cpu/acmeshell/acme/resources"
 	"github.com/cpu/acmeshell/shell/commands"
 )
@@ -22,71 +25,133 @@ func init() {
 
 type newOrderOptions struct {
 	rawIdentifiers string
+	replaces       string
+	replacesOrder  int
+	notBefore      string
+	notAfter       string
+	profile        string
 }
 
 func newOrderHandler(c *ishell.Context) {
 	opts := newOrderOptions{}
 	newOrderFlags := flag.NewFlagSet("newOrder", flag.ContinueOnError)
-	newOrderFlags.StringVar(&opts.rawIdentifiers, "identifiers", "", "Comma separated list of DNS identifiers")
+	newOrderFlags.StringVar(&opts.rawIdentifiers, "identifiers", "", "Comma separated list of DNS or IP address identifiers")
+	newOrderFlags.StringVar(&opts.replaces, "replaces", "", "ARI certificate ID of a certificate the order replaces")
+	newOrderFlags.IntVar(&opts.replacesOrder, "replacesOrder", -1, "index of existing order with a certificate the order replaces")
+	newOrderFlags.StringVar(&opts.notBefore, "notBefore", "", "Requested certificate notBefore as an RFC 3339 timestamp or relative duration (e.g. +1h)")
+	newOrderFlags.StringVar(&opts.notAfter, "notAfter", "", "Requested certificate notAfter as an RFC 3339 timestamp or relative duration (e.g. +72h)")
+	newOrderFlags.StringVar(&opts.profile, "profile", "", "Name of a certificate profile advertised by the server (see profiles)")
 
-	if _, err := commands.ParseFlagSetArgs(c.Args, newOrderFlags); err != nil {
+	if _, err := commands.ParseFlags(c, newOrderFlags); err != nil {
 		return
 	}
 
+	if opts.replaces != "" && opts.replacesOrder != -1 {
+		commands.Failf(c, "newOrder: -replaces and -replacesOrder are mutually exclusive\n")
+		return
+	}
+
+	client := commands.GetClient(c)
+
+	if opts.profile != "" {
+		if err := checkProfile(client, opts.profile); err != nil {
+			commands.Failf(c, "newOrder: %v\n", err)
+			return
+		}
+	}
+
+	if opts.replacesOrder != -1 {
+		order, err := client.OrderByIndex(opts.replacesOrder)
+		if err != nil {
+			commands.Failf(c, "newOrder: error getting -replacesOrder order: %v\n", err)
+			return
+		}
+		opts.replaces, err = client.OrderARICertID(order)
+		if err != nil {
+			commands.Failf(c, "newOrder: error computing ARI cert ID for -replacesOrder: %v\n", err)
+			return
+		}
+	}
+
 	if opts.rawIdentifiers != "" {
 		rawIdentifiers := strings.Split(opts.rawIdentifiers, ",")
 		if len(rawIdentifiers) > 0 {
-			createOrder(c, rawIdentifiers)
+			createOrder(c, rawIdentifiers, opts)
 			return
 		}
 	}
 
 	inputIdentifiers := readIdentifiers(c)
 	if inputIdentifiers == "" {
-		c.Printf("No identifiers provided.\n")
+		commands.Failf(c, "No identifiers provided.\n")
 		return
 	}
 
-	createOrder(c, strings.Split(inputIdentifiers, "\n"))
+	createOrder(c, strings.Split(inputIdentifiers, "\n"), opts)
 }
 
 func readIdentifiers(c *ishell.Context) string {
-	c.SetPrompt(commands.BasePrompt + "FQDN > ")
+	c.SetPrompt(commands.BasePrompt + "FQDN/IP > ")
 	defer c.SetPrompt(commands.BasePrompt)
 	terminator := "."
-	c.Printf("Input fully qualified domain name identifiers for your order. "+
+	c.Printf("Input fully qualified domain name or IP address identifiers for your order. "+
 		" End by sending '%s'\n", terminator)
 	return strings.TrimSuffix(c.ReadMultiLines(terminator), terminator)
 }
 
-func createOrder(c *ishell.Context, fqdns []string) {
+func createOrder(c *ishell.Context, values []string, opts newOrderOptions) {
 	var idents []resources.Identifier
-	// Convert the fqdns to DNS identifiers
-	for _, ident := range fqdns {
+	// Convert the values to IP identifiers for IP addresses and DNS identifiers
+	// for everything else
+	for _, ident := range values {
 		val := strings.TrimSpace(ident)
 		if val == "" {
 			continue
 		}
-		idents = append(idents, resources.Identifier{
-			Type:  "dns",
-			Value: val,
-		})
+		idents = append(idents, resources.NewIdentifier(val))
 	}
 
 	client := commands.GetClient(c)
 	order := &resources.Order{
 		Identifiers: idents,
+		Replaces:    opts.replaces,
+		NotBefore:   opts.notBefore,
+		NotAfter:    opts.notAfter,
+		Profile:     opts.profile,
 	}
 	err := client.CreateOrder(order)
 	if err != nil {
-		c.Printf("newOrder: error creating new order with ACME server: %s\n", err)
+		commands.Failf(c, "newOrder: error creating new order with ACME server: %s\n", err)
 		return
 	}
 
+	commands.SetResource(c, order)
 	orderStr, err := commands.PrintJSON(order)
 	if err != nil {
-		c.Printf("getOrder: error serializing order: %v\n", err)
+		commands.Failf(c, "getOrder: error serializing order: %v\n", err)
 		return
 	}
 	c.Printf("%s\n", orderStr)
 }
+
+// checkProfile returns an error if the named profile is not one of the profiles
+// advertised in the ACME server's directory.
+func checkProfile(client *acmeclient.Client, profile string) error {
+	profiles, err := client.Profiles()
+	if err != nil {
+		return fmt.Errorf("error getting server profiles: %w", err)
+	}
+	if len(profiles) == 0 {
+		return fmt.Errorf("ACME server does not advertise any profiles")
+	}
+	if _, ok := profiles[profile]; !ok {
+		var names []string
+		for name := range profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown profile %q. Server profiles: %s",
+			profile, strings.Join(names, ", "))
+	}
+	return nil
+}
@@ -13,7 +13,7 @@ func init() {
 	commands.RegisterCommand(
 		&ishell.Cmd{
 			Name:     "orders",
-			Help:     "Show ACME orders created in this session by the active account",
+			Help:     "Show ACME orders created by the active account",
 			LongHelp: `TODO(@cpu): write this`,
 			Func:     ordersHandler,
 		},
@@ -24,6 +24,7 @@ type ordersOptions struct {
 	printID          bool
 	printIdentifiers bool
 	status           string
+	remote           bool
 }
 
 func ordersHandler(c *ishell.Context) {
@@ -32,35 +33,53 @@ func ordersHandler(c *ishell.Context) {
 	ordersFlags.BoolVar(&opts.printID, "showID", true, "Print order IDs")
 	ordersFlags.BoolVar(&opts.printIdentifiers, "showIdents", true, "Print order identifiers")
 	ordersFlags.StringVar(&opts.status, "status", "", "Print orders only if they are in the given status")
+	ordersFlags.BoolVar(&opts.remote, "remote", false, "Sync the active account's orders list from the server before printing")
 
-	if _, err := commands.ParseFlagSetArgs(c.Args, ordersFlags); err != nil {
+	if _, err := commands.ParseFlags(c, ordersFlags); err != nil {
 		return
 	}
 
 	if !opts.printID && !opts.printIdentifiers {
-		c.Printf("orders: -showID and -showIdents can not both be false\n")
+		commands.Failf(c, "orders: -showID and -showIdents can not both be false\n")
 		return
 	}
 
 	client := commands.GetClient(c)
+
+	if opts.remote {
+		remoteOrders, err := client.SyncOrders()
+		if err != nil {
+			commands.Failf(c, "orders: error syncing orders from server: %v\n", err)
+			return
+		}
+		c.Printf("orders: server returned %d orders for the active account\n", len(remoteOrders))
+	}
+
 	orders := client.ActiveAccount.Orders
 	if len(orders) == 0 {
 		c.Printf("orders: the active account has no orders\n")
 		return
 	}
 
+	summaries := make([]orderSummary, 0, len(orders))
 	for i, orderURL := range orders {
 		order := &resources.Order{
 			ID: orderURL,
 		}
 		err := client.UpdateOrder(order)
 		if err != nil {
-			c.Printf("orders: error getting order object: %s\n", err.Error())
+			commands.Failf(c, "orders: error getting order object: %s\n", err.Error())
 			return
 		}
 		if opts.status != "" && order.Status != opts.status {
 			continue
 		}
+		summaries = append(summaries, orderSummary{
+			Index:       i,
+			ID:          order.ID,
+			Identifiers: order.Identifiers,
+			Status:      order.Status,
+		})
 		c.Printf("%3d)", i)
 		if opts.printID {
 			c.Printf("\t%#q", order.ID)
@@ -75,4 +94,14 @@ func ordersHandler(c *ishell.Context) {
 		c.Printf("\t%s", order.Status)
 		c.Printf("\n")
 	}
+	commands.SetResource(c, summaries)
+}
+
+// orderSummary is the representation of an order reported by the orders
+// command.
+type orderSummary struct {
+	Index       int                    `json:"index"`
+	ID          string                 `json:"id"`
+	Identifiers []resources.Identifier `json:"identifiers"`
+	Status      string                 `json:"status"`
 }
@@ -0,0 +1,295 @@
+package commands
+
+import (
+	"crypto"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/abiosoft/ishell"
+	"github.com/cpu/acmeshell/acme/keys"
+	"github.com/cpu/acmeshell/acme/resources"
+)
+
+const (
+	// TextOutput is the output format where commands print free-form text.
+	TextOutput = "text"
+	// JSONOutput is the output format where every command invocation emits one
+	// JSON CommandResult object.
+	JSONOutput = "json"
+)
+
+// CommandResult is the JSON object emitted for a command invocation in the
+// JSON output format.
+type CommandResult struct {
+	// The name of the command that was run.
+	Command string `json:"command"`
+	// The arguments the command was run with.
+	Args []string `json:"args,omitempty"`
+	// True if the command did not report an error.
+	Success bool `json:"success"`
+	// The URL of the resource the command acted on (if any).
+	URL string `json:"url,omitempty"`
+	// The status of the resource the command acted on (if any).
+	Status string `json:"status,omitempty"`
+	// The resource the command produced or acted on (if any).
+	Resource any `json:"resource,omitempty"`
+	// The error the command stopped with (if any).
+	Error string `json:"error,omitempty"`
+	// The ACME problem document of the error (if any).
+	Problem *resources.Problem `json:"problem,omitempty"`
+	// Any other text the command printed, one entry per line.
+	Output []string `json:"output,omitempty"`
+}
+
+// commandOutput is the output layer of a single command invocation. It wraps
+// the ishell.Actions of the invocation's context so that in the JSON output
+// format text printed by the command is collected instead of written to the
+// shell.
+type commandOutput struct {
+	ishell.Actions
+	json   bool
+	result CommandResult
+	text   strings.Builder
+}
+
+func (o *commandOutput) Print(val ...any) {
+	if o.json {
+		fmt.Fprint(&o.text, val...)
+		return
+	}
+	o.Actions.Print(val...)
+}
+
+func (o *commandOutput) Println(val ...any) {
+	if o.json {
+		fmt.Fprintln(&o.text, val...)
+		return
+	}
+	o.Actions.Println(val...)
+}
+
+func (o *commandOutput) Printf(format string, val ...any) {
+	if o.json {
+		fmt.Fprintf(&o.text, format, val...)
+		return
+	}
+	o.Actions.Printf(format, val...)
+}
+
+// emit writes the invocation's CommandResult to the shell as a single line of
+// JSON.
+func (o *commandOutput) emit() {
+	result := o.result
+	result.Success = result.Error == ""
+	for _, line := range strings.Split(o.text.String(), "\n") {
+		if line = strings.TrimRight(line, " \t\r"); line != "" {
+			result.Output = append(result.Output, line)
+		}
+	}
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		resultJSON, _ = json.Marshal(CommandResult{
+			Command: result.Command,
+			Args:    result.Args,
+			Error:   fmt.Sprintf("error serializing command result: %v", err),
+		})
+	}
+	o.Actions.Println(string(resultJSON))
+}
+
+// withOutput wraps the handler of the given command so that the command
+// reports to a commandOutput.
+func withOutput(cmd *ishell.Cmd) func(c *ishell.Context) {
+	handler := cmd.Func
+	return func(c *ishell.Context) {
+		out := &commandOutput{
+			Actions: c.Actions,
+			json:    OutputFormat(c) == JSONOutput,
+			result: CommandResult{
+				Command: cmd.Name,
+				Args:    c.Args,
+			},
+		}
+		c.Actions = out
+		c.Set(outputKey, out)
+		defer func() {
+			c.Actions = out.Actions
+			if out.json {
+				out.emit()
+			}
+		}()
+		handler(c)
+	}
+}
+
+// getOutput returns the commandOutput of the context's command invocation or
+// nil if the command isn't running with an output layer.
+func getOutput(c *ishell.Context) *commandOutput {
+	out, _ := c.Get(outputKey).(*commandOutput)
+	return out
+}
+
+// OutputFormat returns the shell's default output format, TextOutput or
+// JSONOutput.
+func OutputFormat(c shellContext) string {
+	if format, ok := c.Get(OutputFormatKey).(string); ok && format == JSONOutput {
+		return JSONOutput
+	}
+	return TextOutput
+}
+
+// ParseFlags parses the context's args with the flagSet like ParseFlagSetArgs.
+// A -json flag is added to the flagSet (unless the command defines its own)
+// that selects the JSON output format for the invocation. The shell's default
+// output format is used when -json isn't given. Parse errors are reported as
+// the command's error.
+func ParseFlags(c *ishell.Context, flagSet *flag.FlagSet) ([]string, error) {
+	out := getOutput(c)
+	var jsonOutput *bool
+	if out != nil && flagSet != nil && flagSet.Lookup("json") == nil {
+		jsonOutput = flagSet.Bool("json", out.json, "print the command result as a JSON object")
+	}
+
+	leftovers, err := ParseFlagSetArgs(c.Args, flagSet)
+	if jsonOutput != nil {
+		out.json = *jsonOutput
+	}
+	if err != nil && !errors.Is(err, flag.ErrHelp) && out != nil {
+		// The flag package has already printed the error.
+		out.result.Error = err.Error()
+	}
+	return leftovers, err
+}
+
+// Failf reports that the command failed. The formatted message is printed
+// like c.Printf in the text output format and becomes the error of the
+// CommandResult in the JSON output format. If one of the args is an error
+// carrying an ACME problem document the problem is included in the
+// CommandResult.
+func Failf(c *ishell.Context, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	out := getOutput(c)
+	if out == nil {
+		c.Printf("%s", msg)
+		return
+	}
+	if !out.json {
+		out.Actions.Printf("%s", msg)
+	}
+	if out.result.Error != "" {
+		return
+	}
+	out.result.Error = strings.TrimSpace(msg)
+	for _, arg := range args {
+		if err, ok := arg.(error); ok {
+			if prob := problemFromError(err); prob != nil {
+				out.result.Problem = prob
+				break
+			}
+		}
+	}
+}
+
+// SetResource reports the resource the command produced or acted on. For ACME
+// orders, authorizations, challenges and accounts the URL and status of the
+// CommandResult are taken from the resource unless they were already set with
+// SetURL and SetStatus. Accounts are reported without their private key.
+func SetResource(c *ishell.Context, resource any) {
+	out := getOutput(c)
+	if out == nil {
+		return
+	}
+	var url, status string
+	switch r := resource.(type) {
+	case *resources.Order:
+		url, status = r.ID, r.Status
+	case *resources.Authorization:
+		url, status = r.ID, r.Status
+	case *resources.Challenge:
+		url, status = r.URL, r.Status
+	case *resources.Account:
+		url, status = r.ID, r.Status
+		resource = accountView{
+			ID:      r.ID,
+			Contact: r.Contact,
+			Status:  r.Status,
+			Orders:  r.Orders,
+			Authzs:  r.Authzs,
+		}
+	case []byte:
+		// Raw JSON response bodies are reported as JSON. The status of ACME
+		// resources is taken from the body.
+		if json.Valid(r) {
+			resource = json.RawMessage(r)
+			var body struct {
+				Status string `json:"status"`
+			}
+			if err := json.Unmarshal(r, &body); err == nil {
+				status = body.Status
+			}
+		} else {
+			resource = string(r)
+		}
+	}
+	out.result.Resource = resource
+	if out.result.URL == "" {
+		out.result.URL = url
+	}
+	if out.result.Status == "" {
+		out.result.Status = status
+	}
+}
+
+// SetURL reports the URL of the resource the command acted on.
+func SetURL(c *ishell.Context, url string) {
+	if out := getOutput(c); out != nil {
+		out.result.URL = url
+	}
+}
+
+// SetStatus reports the status of the resource the command acted on.
+func SetStatus(c *ishell.Context, status string) {
+	if out := getOutput(c); out != nil {
+		out.result.Status = status
+	}
+}
+
+// SetProblem reports the ACME problem document of the command's error. It is
+// only needed for problems that aren't carried by an error passed to Failf.
+func SetProblem(c *ishell.Context, prob *resources.Problem) {
+	if out := getOutput(c); out != nil && prob != nil {
+		out.result.Problem = prob
+	}
+}
+
+// accountView is the representation of a resources.Account reported by
+// SetResource. It omits the account's Signer.
+type accountView struct {
+	ID      string   `json:"id"`
+	Contact []string `json:"contact,omitempty"`
+	Status  string   `json:"status,omitempty"`
+	Orders  []string `json:"orders,omitempty"`
+	Authzs  []string `json:"authzs,omitempty"`
+}
+
+// KeySummary is the public description of a shell key reported with
+// SetResource.
+type KeySummary struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	JWK        json.RawMessage `json:"jwk"`
+	Thumbprint string          `json:"thumbprint"`
+}
+
+// SummarizeKey returns the KeySummary of the key with the given ID.
+func SummarizeKey(id string, signer crypto.Signer) KeySummary {
+	return KeySummary{
+		ID:         id,
+		Type:       describePublicKey(signer.Public()),
+		JWK:        json.RawMessage(keys.JWKJSON(signer)),
+		Thumbprint: keys.JWKThumbprint(signer),
+	}
+}
@@ -42,6 +42,33 @@ func PickOrder(c *ishell.Context) (*resources.Order, error) {
 	return order, nil
 }
 
+// PickOrderOrStandaloneURL interactively picks one of the active account's
+// orders or its standalone authorizations created with newAuthz. The picked
+// order URL is returned, or an empty string if the standalone authorizations
+// were picked.
+func PickOrderOrStandaloneURL(c *ishell.Context) (string, error) {
+	acct := GetClient(c).ActiveAccount
+	if len(acct.Authzs) == 0 {
+		return PickOrderURL(c)
+	}
+	if len(acct.Orders) == 0 {
+		return "", nil
+	}
+
+	choiceList := make([]string, 0, len(acct.Orders)+1)
+	for i, orderURL := range acct.Orders {
+		choiceList = append(choiceList, fmt.Sprintf("%3d) %s", i, orderURL))
+	}
+	choiceList = append(choiceList,
+		fmt.Sprintf("     %d standalone authorization(s)", len(acct.Authzs)))
+
+	choice := c.MultiChoice(choiceList, "Select an order or the standalone authorizations")
+	if choice == len(acct.Orders) {
+		return "", nil
+	}
+	return acct.Orders[choice], nil
+}
+
 func PickAuthzURL(c *ishell.Context, order *resources.Order) (string, error) {
 	if len(order.Authorizations) == 0 {
 		return "", errors.New("order has no authorizations")
@@ -51,6 +78,24 @@ func PickAuthzURL(c *ishell.Context, order *resources.Order) (string, error) {
 	return order.Authorizations[choice], nil
 }
 
+// PickStandaloneAuthzURL interactively picks one of the active account's
+// standalone authorizations created with newAuthz.
+func PickStandaloneAuthzURL(c *ishell.Context) (string, error) {
+	client := GetClient(c)
+	if len(client.ActiveAccount.Authzs) == 0 {
+		return "", fmt.Errorf("active account has no standalone authorizations")
+	}
+
+	authzList := make([]string, len(client.ActiveAccount.Authzs))
+	for i, authzURL := range client.ActiveAccount.Authzs {
+		line := fmt.Sprintf("%3d) %s", i, authzURL)
+		authzList[i] = line
+	}
+
+	choice := c.MultiChoice(authzList, "Select a standalone authorization")
+	return client.ActiveAccount.Authzs[choice], nil
+}
+
 func PickAuthz(c *ishell.Context, order *resources.Order) (*resources.Authorization, error) {
 	client := GetClient(c)
 
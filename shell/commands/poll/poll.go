@@ -1,13 +1,11 @@
 package poll
 
 import (
-	"encoding/json"
 	"flag"
 	"time"
 
 	"github.com/abiosoft/ishell"
 	acmeclient "github.com/cpu/acmeshell/acme/client"
-	"github.com/cpu/acmeshell/net"
 	"github.com/cpu/acmeshell/shell/commands"
 )
 
@@ -15,7 +13,7 @@ func init() {
 	commands.RegisterCommand(
 		&ishell.Cmd{
 			Name:     "poll",
-			Help:     "Poll an order or authz until it is has the desired status field value",
+			Help:     "Poll an order, authz or challenge until it is has the desired status field value",
 			LongHelp: `TODO(@cpu): Write the poll cmd longHelp`,
 			Func:     pollHandler,
 		},
@@ -23,107 +21,111 @@ func init() {
 }
 
 type pollOptions struct {
-	maxTries     int
-	sleepSeconds int
-	status       string
-	orderIndex   int
-	identifier   string
+	maxTries        int
+	sleepSeconds    int
+	maxSleepSeconds int
+	backoff         float64
+	timeout         time.Duration
+	status          string
+	orderIndex      int
+	authzIndex      int
+	identifier      string
+	challType       string
 }
 
 func pollHandler(c *ishell.Context) {
 	opts := pollOptions{}
 	pollFlags := flag.NewFlagSet("poll", flag.ContinueOnError)
-	pollFlags.StringVar(&opts.status, "status", "ready", "Poll object until it is the given status")
-	pollFlags.IntVar(&opts.maxTries, "maxTries", 5, "Number of times to poll before giving up")
+	pollFlags.StringVar(&opts.status, "status", "", `Poll object until it is the given status (default "ready" for orders, "valid" for authzs and challenges)`)
+	pollFlags.IntVar(&opts.maxTries, "maxTries", 5, "Number of times to poll before giving up (0 for no limit)")
 	pollFlags.IntVar(&opts.sleepSeconds, "sleep", 5, "Number of seconds to sleep between poll attempts")
+	pollFlags.IntVar(&opts.maxSleepSeconds, "maxSleep", 60, "Maximum number of seconds to sleep between poll attempts when backing off")
+	pollFlags.Float64Var(&opts.backoff, "backoff", 1, "Factor to multiply the sleep by after each poll attempt (1 for a fixed sleep)")
+	pollFlags.DurationVar(&opts.timeout, "timeout", 0, "Maximum total time to poll for (0 for no limit)")
 	pollFlags.IntVar(&opts.orderIndex, "order", -1, "index of order to poll")
+	pollFlags.IntVar(&opts.authzIndex, "authz", -1, "index of standalone authorization to poll (see newAuthz)")
 	pollFlags.StringVar(&opts.identifier, "identifier", "", "identifier of authorization")
+	pollFlags.StringVar(&opts.challType, "challengeType", "", "type of challenge to poll (requires an authorization)")
 
-	leftovers, err := commands.ParseFlagSetArgs(c.Args, pollFlags)
+	leftovers, err := commands.ParseFlags(c, pollFlags)
 	if err != nil {
 		return
 	}
 
 	client := commands.GetClient(c)
 
-	targetURL, err := commands.FindOrderURL(c, leftovers, opts.orderIndex)
-	if err != nil {
-		c.Printf("poll: error getting order URL: %v\n", err)
-		return
+	// Authorizations and challenges are polled when a standalone authz,
+	// identifier or challenge type is specified. Otherwise the order is polled.
+	pollAuthz := opts.authzIndex != -1 || opts.identifier != "" || opts.challType != ""
+
+	var targetURL string
+	if pollAuthz && len(leftovers) == 0 {
+		targetURL, err = commands.FindAnyAuthzURL(c, opts.orderIndex, opts.authzIndex, opts.identifier)
+		if err != nil {
+			commands.Failf(c, "poll: error getting authz URL: %v\n", err)
+			return
+		}
+	} else {
+		targetURL, err = commands.FindOrderURL(c, leftovers, opts.orderIndex)
+		if err != nil {
+			commands.Failf(c, "poll: error getting order URL: %v\n", err)
+			return
+		}
+		if pollAuthz {
+			targetURL, err = commands.FindAuthzURL(c, targetURL, opts.identifier)
+			if err != nil {
+				commands.Failf(c, "poll: error getting authz URL: %v\n", err)
+				return
+			}
+		}
 	}
 
-	if opts.identifier != "" {
-		targetURL, err = commands.FindAuthzURL(c, targetURL, opts.identifier)
+	if opts.challType != "" {
+		targetURL, err = commands.FindChallengeURL(c, targetURL, opts.challType)
 		if err != nil {
-			c.Printf("poll: error getting order URL: %v\n", err)
+			commands.Failf(c, "poll: error getting challenge URL: %v\n", err)
 			return
 		}
 	}
 
 	// Shouldn't happen...
 	if targetURL == "" {
-		c.Printf("poll: error, no targetURL\n")
+		commands.Failf(c, "poll: error, no targetURL\n")
 		return
 	}
 
-	pollURL(c, client, targetURL, opts)
-}
-
-type polledOb struct {
-	Status string
-}
-
-func pollObject(client *acmeclient.Client, targetURL string, opts pollOptions) (polledOb, error) {
-	var ob polledOb
-	var resp *net.NetResponse
-	var err error
-	if client.PostAsGet {
-		resp, err = client.PostAsGetURL(targetURL)
-	} else {
-		resp, err = client.GetURL(targetURL)
+	status := "ready"
+	if pollAuthz {
+		status = "valid"
 	}
-	if err != nil {
-		return ob, err
+	if opts.status != "" {
+		status = opts.status
 	}
 
-	err = json.Unmarshal(resp.RespBody, &ob)
-	if err != nil {
-		return ob, err
+	pollOpts := acmeclient.PollOptions{
+		Status:      status,
+		Interval:    time.Duration(opts.sleepSeconds) * time.Second,
+		MaxInterval: time.Duration(opts.maxSleepSeconds) * time.Second,
+		Backoff:     opts.backoff,
+		MaxTries:    opts.maxTries,
+		Timeout:     opts.timeout,
+		OnPoll: func(try int, status string, delay time.Duration) {
+			c.Printf("poll: try %d. %q is status %q. Sleeping %s\n", try, targetURL, status, delay)
+		},
 	}
-	return ob, nil
-}
 
-func pollURL(c *ishell.Context, client *acmeclient.Client, targetURL string, opts pollOptions) {
-	ob, err := pollObject(client, targetURL, opts)
-	if err != nil {
-		c.Printf("poll: error polling object at %q: %v\n", targetURL, err)
-		return
+	polled, err := client.Poll(targetURL, pollOpts)
+	commands.SetURL(c, targetURL)
+	if polled != nil {
+		commands.SetResource(c, polled.Body)
+		commands.SetStatus(c, polled.Status)
 	}
-
-	if ob.Status != opts.status {
-		for try := 0; try < opts.maxTries; try++ {
-			ob, err = pollObject(client, targetURL, opts)
-			if err != nil {
-				c.Printf("poll: error polling object at %q: %v\n", targetURL, err)
-				return
-			}
-			if ob.Status == opts.status {
-				break
-			}
-
-			c.Printf("poll: try %d. %q is status %q\n", try, targetURL, ob.Status)
-			time.Sleep(time.Duration(opts.sleepSeconds) * time.Second)
+	if err != nil {
+		if polled != nil {
+			commands.SetProblem(c, polled.Error)
 		}
+		commands.Failf(c, "poll: polling failed: %v\n", err)
+		return
 	}
-
-	if ob.Status == opts.status {
-		c.Printf("poll: polling done. %q is status %q\n",
-			targetURL,
-			ob.Status)
-	} else {
-		c.Printf("poll: polling failed. reached %d tries. %q is status %q\n",
-			opts.maxTries,
-			targetURL,
-			ob.Status)
-	}
+	c.Printf("poll: polling done. %q is status %q\n", targetURL, polled.Status)
 }
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/abiosoft/ishell"
+	"github.com/cpu/acmeshell/net"
 	"github.com/cpu/acmeshell/shell/commands"
 )
 
@@ -63,7 +64,7 @@ func postHandler(c *ishell.Context) {
 	postFlags.BoolVar(&opts.sign, "sign", true, "Sign body with active account key")
 	postFlags.BoolVar(&opts.noData, "noData", false, "Skip -body and assume no data POST-as-GET")
 
-	leftovers, err := commands.ParseFlagSetArgs(c.Args, postFlags)
+	leftovers, err := commands.ParseFlags(c, postFlags)
 	if err != nil {
 		return
 	}
@@ -72,13 +73,13 @@ func postHandler(c *ishell.Context) {
 
 	targetURL, err := commands.FindURL(client, leftovers)
 	if err != nil {
-		c.Printf("post: error finding URL: %v", err)
+		commands.Failf(c, "post: error finding URL: %v", err)
 		return
 	}
 
 	// Check the URL and make sure it is valid-ish
 	if !commands.OkURL(targetURL) {
-		c.Printf("post: illegal url argument %q\n", targetURL)
+		commands.Failf(c, "post: illegal url argument %q\n", targetURL)
 		return
 	}
 
@@ -86,7 +87,7 @@ func postHandler(c *ishell.Context) {
 	var body []byte
 
 	if len(trimmedBodyArg) > 0 && opts.noData {
-		c.Printf("post: -body and -noData are mutually exclusive\n")
+		commands.Failf(c, "post: -body and -noData are mutually exclusive\n")
 		return
 	} else if len(trimmedBodyArg) > 0 {
 		body = []byte(trimmedBodyArg)
@@ -94,7 +95,7 @@ func postHandler(c *ishell.Context) {
 		// Otherwise, read the POST body interactively
 		inputJSON := commands.ReadJSON(c)
 		if inputJSON == "" {
-			c.Printf("post: no POST body provided\n")
+			commands.Failf(c, "post: no POST body provided\n")
 			return
 		}
 		body = []byte(inputJSON)
@@ -106,7 +107,7 @@ func postHandler(c *ishell.Context) {
 		// Render the body input as a template
 		rendered, err := commands.ClientTemplate(client, string(body))
 		if err != nil {
-			c.Printf("post: warning: target URL templating error: %s\n", err.Error())
+			commands.Failf(c, "post: warning: target URL templating error: %s\n", err.Error())
 			return
 		}
 		body = []byte(rendered)
@@ -119,24 +120,24 @@ func postURL(c *ishell.Context, targetURL string, body []byte, sign bool) {
 	client := commands.GetClient(c)
 	account := client.ActiveAccount
 
-	if sign {
-		if account == nil {
-			c.Printf("post: no active ACME account to authenticate POST requests\n")
-			return
-		}
-		signResult, err := client.Sign(targetURL, body, nil)
-		if err != nil {
-			c.Printf("post: error signing POST request body: %s\n", err)
-			return
-		}
-		body = signResult.SerializedJWS
+	if sign && account == nil {
+		commands.Failf(c, "post: no active ACME account to authenticate POST requests\n")
+		return
 	}
 
 	log.Printf("Sending HTTP POST request to %q", targetURL)
-	resp, err := client.PostURL(targetURL, body)
+	var resp *net.NetResponse
+	var err error
+	if sign {
+		resp, err = client.PostSignedURL(targetURL, body, nil)
+	} else {
+		resp, err = client.PostURL(targetURL, body)
+	}
 	if err != nil {
-		c.Printf("post: error POSTing signed request body to URL: %v\n", err)
+		commands.Failf(c, "post: error POSTing signed request body to URL: %v\n", err)
 		return
 	}
+	commands.SetResource(c, resp.RespBody)
+	commands.SetURL(c, targetURL)
 	c.Printf("%s\n", resp.RespBody)
 }
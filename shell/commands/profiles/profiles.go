@@ -0,0 +1,52 @@
+package profiles
+
+import (
+	"flag"
+	"sort"
+
+	"github.com/abiosoft/ishell"
+	"github.com/cpu/acmeshell/shell/commands"
+)
+
+func init() {
+	commands.RegisterCommand(
+		&ishell.Cmd{
+			Name:     "profiles",
+			Help:     "List the certificate profiles advertised by the ACME server",
+			LongHelp: `TODO(@cpu): Write this!`,
+			Func:     profilesHandler,
+		},
+		nil)
+}
+
+func profilesHandler(c *ishell.Context) {
+	profilesFlags := flag.NewFlagSet("profiles", flag.ContinueOnError)
+	if _, err := commands.ParseFlags(c, profilesFlags); err != nil {
+		return
+	}
+
+	client := commands.GetClient(c)
+
+	profiles, err := client.Profiles()
+	if err != nil {
+		commands.Failf(c, "profiles: error getting server profiles: %v\n", err)
+		return
+	}
+
+	if len(profiles) == 0 {
+		c.Printf("ACME server does not advertise any profiles\n")
+		return
+	}
+
+	commands.SetResource(c, profiles)
+
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		c.Printf("%s: %s\n", name, profiles[name])
+	}
+}
@@ -0,0 +1,113 @@
+package recoverAccount
+
+import (
+	"crypto"
+	"flag"
+	"os"
+
+	"github.com/abiosoft/ishell"
+	"github.com/cpu/acmeshell/acme/keys"
+	"github.com/cpu/acmeshell/acme/resources"
+	"github.com/cpu/acmeshell/shell/commands"
+)
+
+func init() {
+	commands.RegisterCommand(
+		&ishell.Cmd{
+			Name:     "recoverAccount",
+			Aliases:  []string{"recoverAcct", "recoverReg", "recoverRegistration"},
+			Help:     "Recover an existing ACME account using its private key",
+			LongHelp: `TODO(@cpu): Write this!`,
+			Func:     recoverAccountHandler,
+		},
+		nil)
+}
+
+type recoverAccountOptions struct {
+	keyID    string
+	pemPath  string
+	switchTo bool
+	jsonPath string
+}
+
+func recoverAccountHandler(c *ishell.Context) {
+	opts := recoverAccountOptions{}
+	recoverAccountFlags := flag.NewFlagSet("recoverAccount", flag.ContinueOnError)
+	recoverAccountFlags.StringVar(&opts.keyID, "keyID", "", "Key ID for an existing key in the shell")
+	recoverAccountFlags.StringVar(&opts.pemPath, "pem", "", "Filepath to a PEM, DER or JWK encoded account private key")
+	recoverAccountFlags.BoolVar(&opts.switchTo, "switch", true, "Switch to the account after recovering it")
+	recoverAccountFlags.StringVar(&opts.jsonPath, "json", "", "Optional filepath to a JSON save file for the account")
+
+	if _, err := commands.ParseFlags(c, recoverAccountFlags); err != nil {
+		return
+	}
+
+	if (opts.keyID == "") == (opts.pemPath == "") {
+		commands.Failf(c, "recoverAccount: exactly one of -keyID or -pem must be provided\n")
+		return
+	}
+
+	client := commands.GetClient(c)
+
+	var acctKey crypto.Signer
+	if opts.keyID != "" {
+		key, found := client.Keys[opts.keyID]
+		if !found {
+			commands.Failf(c, "recoverAccount: Key ID %q does not exist in shell\n", opts.keyID)
+			return
+		}
+		acctKey = key
+	} else {
+		keyBytes, err := os.ReadFile(opts.pemPath)
+		if err != nil {
+			commands.Failf(c, "recoverAccount: error reading key from file %q: %v\n", opts.pemPath, err)
+			return
+		}
+		key, err := keys.ParseSigner(keyBytes)
+		if err != nil {
+			commands.Failf(c, "recoverAccount: error loading private key from %q: %v\n", opts.pemPath, err)
+			return
+		}
+		acctKey = key
+	}
+
+	acct, err := client.LookupAccount(acctKey)
+	if err != nil {
+		commands.Failf(c, "recoverAccount: error finding existing account with ACME server: %v\n", err)
+		return
+	}
+
+	// TODO(@cpu): Maintain a map of account IDs to avoid this o(n) check
+	for i, existingAcct := range client.Accounts {
+		if acct.ID == existingAcct.ID {
+			commands.Failf(c, "recoverAccount: %q is already loaded as account # %d\n", acct.ID, i)
+			return
+		}
+	}
+
+	// keys loaded from a file are stored under the account ID the same way
+	// newAccount stores generated keys
+	if opts.pemPath != "" {
+		client.Keys[acct.ID] = acct.Signer
+		c.Printf("Restored private key %q\n", acct.ID)
+	}
+
+	c.Printf("Recovered account with ID %q (Status %q Contact %s)\n",
+		acct.ID, acct.Status, acct.Contact)
+	client.Accounts = append(client.Accounts, acct)
+	commands.SetResource(c, acct)
+
+	if opts.jsonPath != "" {
+		if err := resources.SaveAccount(opts.jsonPath, acct); err != nil {
+			commands.Failf(c, "recoverAccount: error saving account to %q : %v\n", opts.jsonPath, err)
+			return
+		}
+		c.Printf("Saved account data to %q\n", opts.jsonPath)
+	}
+
+	if opts.switchTo {
+		// use the recovered account immediately
+		client.ActiveAccount = acct
+		c.Printf("Active account is now %q\n", client.ActiveAccount.ID)
+	}
+}
@@ -0,0 +1,125 @@
+package renew
+
+import (
+	"flag"
+	"strings"
+	"time"
+
+	"github.com/abiosoft/ishell"
+	acmeclient "github.com/cpu/acmeshell/acme/client"
+	"github.com/cpu/acmeshell/acme/keys"
+	"github.com/cpu/acmeshell/acme/resources"
+	"github.com/cpu/acmeshell/shell/commands"
+)
+
+func init() {
+	commands.RegisterCommand(
+		&ishell.Cmd{
+			Name:     "renew",
+			Aliases:  []string{"renewCert", "renewCertificate"},
+			Help:     "Issue a replacement certificate for an existing order's identifiers",
+			LongHelp: `TODO(@cpu): Write this!`,
+			Func:     renewHandler,
+		},
+		nil)
+}
+
+type renewOptions struct {
+	orderIndex   int
+	newKey       bool
+	keyType      string
+	challType    string
+	pemPath      string
+	replaces     bool
+	sleepSeconds int
+	timeout      time.Duration
+}
+
+func renewHandler(c *ishell.Context) {
+	opts := renewOptions{}
+	renewFlags := flag.NewFlagSet("renew", flag.ContinueOnError)
+	renewFlags.IntVar(&opts.orderIndex, "order", -1, "index of existing order with the certificate to renew")
+	renewFlags.BoolVar(&opts.newKey, "newKey", false, "generate a new key for the CSR instead of reusing the original certificate's key")
+	renewFlags.StringVar(&opts.keyType, "keyType", "p256", "Type of key to generate with -newKey: "+strings.Join(keys.KeySpecs, ", "))
+	renewFlags.StringVar(&opts.challType, "challengeType", "http-01", "Challenge type to solve for each authorization")
+	renewFlags.StringVar(&opts.pemPath, "path", "", "file path to save the PEM certificate chain to (default: print the chain)")
+	renewFlags.BoolVar(&opts.replaces, "replaces", true, "set the new order's ARI replaces field to the original certificate when the server supports ARI")
+	renewFlags.IntVar(&opts.sleepSeconds, "sleep", 1, "Number of seconds to sleep between the first poll attempts")
+	renewFlags.DurationVar(&opts.timeout, "timeout", 2*time.Minute, "Maximum time to wait for the authorizations, and for the order to be ready and valid")
+
+	leftovers, err := commands.ParseFlags(c, renewFlags)
+	if err != nil {
+		return
+	}
+
+	client := commands.GetClient(c)
+
+	orderURL, err := commands.FindOrderURL(c, leftovers, opts.orderIndex)
+	if err != nil {
+		commands.Failf(c, "renew: error getting order URL: %v\n", err)
+		return
+	}
+	original := &resources.Order{
+		ID: orderURL,
+	}
+	if err := client.UpdateOrder(original); err != nil {
+		commands.Failf(c, "renew: error getting order: %v\n", err)
+		return
+	}
+	pemBytes, err := client.GetCertificate(original)
+	if err != nil {
+		commands.Failf(c, "renew: error getting certificate of order %q: %v\n", original.ID, err)
+		return
+	}
+	certs, err := acmeclient.ParseCertificates(pemBytes)
+	if err != nil {
+		commands.Failf(c, "renew: error parsing certificate of order %q: %v\n", original.ID, err)
+		return
+	}
+	cert := certs[0]
+
+	var keyID string
+	if !opts.newKey {
+		var found bool
+		keyID, found = client.CertificateKeyID(cert)
+		if !found {
+			commands.Failf(c, "renew: the key of the certificate of order %q is not in the shell. "+
+				"Load it with loadKey or use -newKey\n", original.ID)
+			return
+		}
+		c.Printf("Reusing certificate key %q\n", keyID)
+	}
+
+	order := &resources.Order{
+		Identifiers: original.Identifiers,
+		Profile:     original.Profile,
+	}
+
+	if opts.replaces {
+		dir, err := client.Directory()
+		if err != nil {
+			commands.Failf(c, "renew: error getting directory: %v\n", err)
+			return
+		}
+		if dir.RenewalInfo != "" {
+			order.Replaces, err = acmeclient.ARICertID(cert)
+			if err != nil {
+				commands.Failf(c, "renew: error computing ARI cert ID: %v\n", err)
+				return
+			}
+			c.Printf("New order replaces certificate %q\n", order.Replaces)
+		} else {
+			c.Printf("ACME server does not support ARI. Not setting replaces\n")
+		}
+	}
+
+	result := commands.Issue(c, order, commands.IssueOptions{
+		ChallengeType: opts.challType,
+		KeyID:         keyID,
+		KeyType:       opts.keyType,
+		CommonName:    cert.Subject.CommonName,
+		PollInterval:  time.Duration(opts.sleepSeconds) * time.Second,
+		Timeout:       opts.timeout,
+	})
+	commands.PrintIssuedCertificate(c, "renew", result, opts.pemPath)
+}
@@ -0,0 +1,134 @@
+package renewalInfo
+
+import (
+	"flag"
+	"os"
+	"time"
+
+	"github.com/abiosoft/ishell"
+	acmeclient "github.com/cpu/acmeshell/acme/client"
+	"github.com/cpu/acmeshell/acme/resources"
+	"github.com/cpu/acmeshell/shell/commands"
+)
+
+const (
+	longHelp = `
+	renewalInfo -order=<order index>:
+		Fetch the ACME Renewal Information (ARI) for the certificate of the order
+		with the given index. The order must be valid.
+
+	renewalInfo -certPEM=<path>:
+		Fetch the ARI for the first certificate in the given PEM file.
+
+	renewalInfo -certID=<ARI certificate ID>:
+		Fetch the ARI for an already computed ARI certificate ID.
+
+	If the server sent a Retry-After header for a previous renewalInfo request
+	for the same certificate the cached result is shown until that time has
+	passed. Use -refresh to ignore the cached result.`
+)
+
+func init() {
+	commands.RegisterCommand(
+		&ishell.Cmd{
+			Name:     "renewalInfo",
+			Aliases:  []string{"ari"},
+			Help:     "Get ACME Renewal Information (ARI) for a certificate",
+			LongHelp: longHelp,
+			Func:     renewalInfoHandler,
+		},
+		nil)
+}
+
+type renewalInfoOptions struct {
+	orderIndex int
+	certPEM    string
+	certID     string
+	refresh    bool
+}
+
+func renewalInfoHandler(c *ishell.Context) {
+	opts := renewalInfoOptions{}
+	renewalInfoFlags := flag.NewFlagSet("renewalInfo", flag.ContinueOnError)
+	renewalInfoFlags.IntVar(&opts.orderIndex, "order", -1, "index of existing order")
+	renewalInfoFlags.StringVar(&opts.certPEM, "certPEM", "", "Path to PEM Certificate file to get renewal info for")
+	renewalInfoFlags.StringVar(&opts.certID, "certID", "", "ARI certificate ID to get renewal info for")
+	renewalInfoFlags.BoolVar(&opts.refresh, "refresh", false, "Ignore a cached result from a previous Retry-After")
+
+	leftovers, err := commands.ParseFlags(c, renewalInfoFlags)
+	if err != nil {
+		return
+	}
+
+	if opts.certPEM != "" && opts.certID != "" {
+		commands.Failf(c, "renewalInfo: -certPEM and -certID are mutually exclusive\n")
+		return
+	}
+	if (opts.certPEM != "" || opts.certID != "") && (len(leftovers) > 0 || opts.orderIndex != -1) {
+		commands.Failf(c, "renewalInfo: -certPEM and -certID can not be used with -order or an order URL\n")
+		return
+	}
+
+	client := commands.GetClient(c)
+
+	certID := opts.certID
+	if opts.certPEM != "" {
+		pemBytes, err := os.ReadFile(opts.certPEM)
+		if err != nil {
+			commands.Failf(c, "renewalInfo: error reading -certPEM argument: %v\n", err)
+			return
+		}
+		certID, err = acmeclient.ARICertIDFromPEM(pemBytes)
+		if err != nil {
+			commands.Failf(c, "renewalInfo: error computing ARI cert ID: %v\n", err)
+			return
+		}
+	} else if certID == "" {
+		orderURL, err := commands.FindOrderURL(c, leftovers, opts.orderIndex)
+		if err != nil {
+			commands.Failf(c, "renewalInfo: error getting order URL: %v\n", err)
+			return
+		}
+		order := &resources.Order{
+			ID: orderURL,
+		}
+		if err := client.UpdateOrder(order); err != nil {
+			commands.Failf(c, "renewalInfo: error getting order: %v\n", err)
+			return
+		}
+		certID, err = client.OrderARICertID(order)
+		if err != nil {
+			commands.Failf(c, "renewalInfo: error computing ARI cert ID: %v\n", err)
+			return
+		}
+	}
+
+	info, err := client.RenewalInfo(certID, opts.refresh)
+	if err != nil {
+		commands.Failf(c, "renewalInfo: error getting renewal info for %q: %v\n", certID, err)
+		return
+	}
+
+	infoStr, err := commands.PrintJSON(info)
+	if err != nil {
+		commands.Failf(c, "renewalInfo: error serializing renewal info: %v\n", err)
+		return
+	}
+	commands.SetResource(c, info)
+	c.Printf("ARI certificate ID: %s\n", certID)
+	c.Printf("%s\n", infoStr)
+
+	now := time.Now()
+	window := info.SuggestedWindow
+	switch {
+	case now.Before(window.Start):
+		c.Printf("Suggested renewal window opens in %s\n", window.Start.Sub(now).Round(time.Second))
+	case now.After(window.End):
+		c.Printf("Suggested renewal window closed %s ago. Renew now!\n", now.Sub(window.End).Round(time.Second))
+	default:
+		c.Printf("Inside the suggested renewal window\n")
+	}
+	if !info.RetryAfter.IsZero() {
+		c.Printf("Server asked to not check again until %s\n", info.RetryAfter.Format(time.RFC3339))
+	}
+}
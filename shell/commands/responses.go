@@ -0,0 +1,384 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cpu/acmeshell/acme"
+	acmeclient "github.com/cpu/acmeshell/acme/client"
+	"github.com/cpu/acmeshell/acme/keys"
+	"github.com/cpu/acmeshell/acme/resources"
+	acmenet "github.com/cpu/acmeshell/net"
+)
+
+// DefaultResponseWatchInterval is the delay between checks of the
+// authorizations of tracked challenge responses.
+const DefaultResponseWatchInterval = 5 * time.Second
+
+// maxAuthzCheckFailures is the number of consecutive failed checks of an
+// authorization after which the responses published for it are removed.
+const maxAuthzCheckFailures = 3
+
+// errAuthzUnavailable is returned (wrapped) by authzStatus when the server
+// refuses to return the authorization, e.g. because the account that owns it
+// was deactivated or the authorization no longer exists.
+var errAuthzUnavailable = errors.New("authorization is unavailable")
+
+// PublishedResponse is a challenge response published on the challenge
+// response server.
+type PublishedResponse struct {
+	// The lowercase challenge type, e.g. "http-01".
+	ChallengeType string `json:"challengeType"`
+	// The HTTP-01 token, the DNS-01 or TLS-ALPN-01 host, or the DNS-ACCOUNT-01
+	// TXT record name the response is published under.
+	Key string `json:"key"`
+	// The published response value.
+	Value string `json:"value"`
+	// The URL of the authorization the response was published for. Empty for
+	// responses published manually with the challSrv command.
+	AuthzURL string `json:"authzURL,omitempty"`
+	// The account that owns the authorization. Nil when AuthzURL is empty.
+	Account *resources.Account `json:"-"`
+	// The time the response was published.
+	Published time.Time `json:"published"`
+}
+
+// ChallengeResponse returns the response the active account must publish to
+// solve the given challenge of the given authorization. The returned
+// PublishedResponse is for the authorization and the active account.
+func ChallengeResponse(
+	client *acmeclient.Client,
+	authz *resources.Authorization,
+	chall *resources.Challenge) (PublishedResponse, error) {
+	if client.ActiveAccountID() == "" {
+		return PublishedResponse{}, fmt.Errorf("active account is nil or has not been created")
+	}
+	keyAuth := keys.KeyAuth(client.ActiveAccount.Signer, chall.Token)
+	ident := authz.Identifier
+	response := PublishedResponse{
+		ChallengeType: strings.ToLower(chall.Type),
+		Value:         keyAuth,
+		AuthzURL:      authz.ID,
+		Account:       client.ActiveAccount,
+	}
+	switch response.ChallengeType {
+	case "http-01":
+		response.Key = chall.Token
+	case "dns-01":
+		if ident.Type == acme.IP_IDENTIFIER {
+			return PublishedResponse{}, fmt.Errorf("dns-01 can not be used for IP identifier %q", ident.Value)
+		}
+		response.Key = ident.Value
+	case "dns-account-01":
+		if ident.Type == acme.IP_IDENTIFIER {
+			return PublishedResponse{}, fmt.Errorf("dns-account-01 can not be used for IP identifier %q", ident.Value)
+		}
+		// The TXT record name is scoped to the account so that multiple accounts
+		// can validate the same name at the same time.
+		response.Key = resources.DNSAccountChallengeName(client.ActiveAccount.ID, ident.Value)
+		response.Value = keys.KeyAuthDigest(keyAuth)
+	case "tls-alpn-01":
+		// For an IP identifier the challenge server answers requests for the
+		// address's reverse DNS name with an iPAddress SAN certificate. See
+		// https://tools.ietf.org/html/rfc8738#section-6
+		response.Key = ident.Value
+	default:
+		return PublishedResponse{}, fmt.Errorf("challenge %q has unknown type: %q", chall.URL, chall.Type)
+	}
+	return response, nil
+}
+
+// ResponseTracker publishes challenge responses on a ChallengeServer and keeps
+// track of them so they can be listed and removed again. Responses published
+// for an authorization are removed automatically by Watch once the
+// authorization reaches a terminal status. A ResponseTracker is safe for
+// concurrent use.
+type ResponseTracker struct {
+	srv ChallengeServer
+
+	mu        sync.Mutex
+	responses []*PublishedResponse
+	// The number of consecutive failed checks of each authorization URL.
+	failures map[string]int
+}
+
+// NewResponseTracker creates a ResponseTracker publishing responses on the
+// given ChallengeServer.
+func NewResponseTracker(srv ChallengeServer) *ResponseTracker {
+	return &ResponseTracker{
+		srv:      srv,
+		failures: map[string]int{},
+	}
+}
+
+// Publish adds the response to the challenge server and tracks it. The
+// AuthzURL and Account fields may be empty for responses that aren't for a
+// known authorization. The challenge server keeps every DNS-01 and
+// DNS-ACCOUNT-01 TXT record value published for a name, so those responses are
+// tracked per value. For the other challenge types a response previously
+// published under the same key is replaced.
+func (t *ResponseTracker) Publish(resp PublishedResponse) error {
+	resp.ChallengeType = strings.ToLower(resp.ChallengeType)
+	if resp.Published.IsZero() {
+		resp.Published = time.Now()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch resp.ChallengeType {
+	case "http-01":
+		t.srv.AddHTTPOneChallenge(resp.Key, resp.Value)
+	case "dns-01":
+		t.srv.AddDNSOneChallenge(resp.Key, resp.Value)
+	case "dns-account-01":
+		t.srv.AddDNSAccountOneChallenge(resp.Key, resp.Value)
+	case "tls-alpn-01":
+		t.srv.AddTLSALPNChallenge(resp.Key, resp.Value)
+	default:
+		return fmt.Errorf("unknown challenge type %q", resp.ChallengeType)
+	}
+	t.untrack(func(tracked *PublishedResponse) bool {
+		return tracked.replacedBy(resp)
+	})
+	t.responses = append(t.responses, &resp)
+	return nil
+}
+
+// Remove deletes the record with the given challenge type and key from the
+// challenge server, including every DNS TXT record value published for it.
+// All responses tracked for the record are forgotten. The record is removed
+// even if it isn't tracked.
+func (t *ResponseTracker) Remove(challType, key string) error {
+	challType = strings.ToLower(challType)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.deleteRecord(challType, key); err != nil {
+		return err
+	}
+	t.untrack(func(tracked *PublishedResponse) bool {
+		return tracked.ChallengeType == challType && tracked.Key == key
+	})
+	return nil
+}
+
+// release forgets the given tracked response and deletes its record from the
+// challenge server, unless another tracked response still uses the record.
+func (t *ResponseTracker) release(resp PublishedResponse) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.untrack(func(tracked *PublishedResponse) bool {
+		return *tracked == resp
+	})
+	for _, tracked := range t.responses {
+		if tracked.ChallengeType == resp.ChallengeType && tracked.Key == resp.Key {
+			return nil
+		}
+	}
+	return t.deleteRecord(resp.ChallengeType, resp.Key)
+}
+
+// deleteRecord deletes the record with the given lowercase challenge type and
+// key from the challenge server. The caller must hold t.mu.
+func (t *ResponseTracker) deleteRecord(challType, key string) error {
+	switch challType {
+	case "http-01":
+		t.srv.DeleteHTTPOneChallenge(key)
+	case "dns-01":
+		t.srv.DeleteDNSOneChallenge(key)
+	case "dns-account-01":
+		t.srv.DeleteDNSAccountOneChallenge(key)
+	case "tls-alpn-01":
+		t.srv.DeleteTLSALPNChallenge(key)
+	default:
+		return fmt.Errorf("unknown challenge type %q", challType)
+	}
+	return nil
+}
+
+// untrack forgets the tracked responses matching the given function. The
+// caller must hold t.mu.
+func (t *ResponseTracker) untrack(matches func(*PublishedResponse) bool) {
+	kept := t.responses[:0]
+	for _, tracked := range t.responses {
+		if !matches(tracked) {
+			kept = append(kept, tracked)
+		}
+	}
+	t.responses = kept
+}
+
+// replacedBy returns true if publishing the other response replaces this
+// response on the challenge server.
+func (resp *PublishedResponse) replacedBy(other PublishedResponse) bool {
+	if resp.ChallengeType != other.ChallengeType || resp.Key != other.Key {
+		return false
+	}
+	switch resp.ChallengeType {
+	case "dns-01", "dns-account-01":
+		return resp.Value == other.Value
+	}
+	return true
+}
+
+// List returns a copy of the tracked responses in the order they were
+// published.
+func (t *ResponseTracker) List() []PublishedResponse {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	results := make([]PublishedResponse, 0, len(t.responses))
+	for _, resp := range t.responses {
+		results = append(results, *resp)
+	}
+	return results
+}
+
+// Clear removes every tracked response from the challenge server and returns
+// the number of responses removed.
+func (t *ResponseTracker) Clear() int {
+	responses := t.List()
+	for _, resp := range responses {
+		_ = t.release(resp)
+	}
+	return len(responses)
+}
+
+// Watch checks the authorizations of the tracked responses every interval and
+// removes the responses of authorizations that reached a terminal status.
+// Authorizations are fetched with the account that published the response.
+// Watch returns a function that stops watching.
+func (t *ResponseTracker) Watch(client *acmeclient.Client, interval time.Duration) func() {
+	if interval <= 0 {
+		interval = DefaultResponseWatchInterval
+	}
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				t.cleanup(client)
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// cleanup fetches the authorization of each tracked response once and removes
+// the responses of authorizations that reached a terminal status. The
+// responses of authorizations that can't be fetched anymore, or that failed to
+// be fetched maxAuthzCheckFailures times in a row, are removed as well.
+func (t *ResponseTracker) cleanup(client *acmeclient.Client) {
+	statuses := map[string]string{}
+	unchecked := map[string]bool{}
+	for _, resp := range t.List() {
+		if resp.AuthzURL == "" {
+			continue
+		}
+		status, checked := statuses[resp.AuthzURL]
+		if !checked {
+			var err error
+			status, err = authzStatus(client, resp.AuthzURL, resp.Account)
+			if err != nil {
+				unchecked[resp.AuthzURL] = t.checkFailed(resp, err)
+			} else {
+				t.resetFailures(resp.AuthzURL)
+			}
+			statuses[resp.AuthzURL] = status
+		}
+		if unchecked[resp.AuthzURL] {
+			_ = t.release(resp)
+			continue
+		}
+		if !acmeclient.IsTerminalStatus(status) {
+			continue
+		}
+		log.Printf("Authz %q is %s. Removing %s challenge response for %q\n",
+			resp.AuthzURL, status, resp.ChallengeType, resp.Key)
+		_ = t.release(resp)
+	}
+}
+
+// checkFailed records a failed check of the authorization of the given
+// response and returns true if the responses published for the authorization
+// should be removed. Only the first failure and the removal are logged so that
+// an unreachable server doesn't log on every check.
+func (t *ResponseTracker) checkFailed(resp PublishedResponse, err error) bool {
+	t.mu.Lock()
+	t.failures[resp.AuthzURL]++
+	failures := t.failures[resp.AuthzURL]
+	t.mu.Unlock()
+
+	switch {
+	case errors.Is(err, errAuthzUnavailable):
+		log.Printf("Authz %q of published %s response can not be fetched: %v. "+
+			"Removing its challenge responses\n", resp.AuthzURL, resp.ChallengeType, err)
+	case failures >= maxAuthzCheckFailures:
+		log.Printf("Checking authz %q of published %s response failed %d times. "+
+			"Removing its challenge responses\n", resp.AuthzURL, resp.ChallengeType, failures)
+	default:
+		if failures == 1 {
+			log.Printf("Error checking authz %q of published %s response (will retry): %v\n",
+				resp.AuthzURL, resp.ChallengeType, err)
+		}
+		return false
+	}
+	t.resetFailures(resp.AuthzURL)
+	return true
+}
+
+// resetFailures forgets the failed checks of the given authorization URL.
+func (t *ResponseTracker) resetFailures(authzURL string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.failures, authzURL)
+}
+
+// authzStatus fetches the status of the authorization at the given URL. With
+// POST-as-GET the request is signed by the given account instead of the active
+// account, which may have changed since the response was published.
+func authzStatus(client *acmeclient.Client, authzURL string, acct *resources.Account) (string, error) {
+	var resp *acmenet.NetResponse
+	var err error
+	if client.PostAsGet {
+		var signOpts *acmeclient.SigningOptions
+		if acct != nil {
+			signOpts = &acmeclient.SigningOptions{
+				KeyID:  acct.ID,
+				Signer: acct.Signer,
+			}
+		}
+		resp, err = client.PostSignedURL(authzURL, []byte(""), signOpts)
+	} else {
+		resp, err = client.GetURL(authzURL)
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := acmeclient.CheckResponse(resp, http.StatusOK); err != nil {
+		switch resp.Response.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+			return "", fmt.Errorf("%w: %w", errAuthzUnavailable, err)
+		}
+		return "", err
+	}
+	var authz struct {
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(resp.RespBody, &authz); err != nil {
+		return "", err
+	}
+	return authz.Status, nil
+}
@@ -0,0 +1,168 @@
+package revocationStatus
+
+import (
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/abiosoft/ishell"
+	acmeclient "github.com/cpu/acmeshell/acme/client"
+	"github.com/cpu/acmeshell/acme/resources"
+	"github.com/cpu/acmeshell/shell/commands"
+)
+
+func init() {
+	commands.RegisterCommand(
+		&ishell.Cmd{
+			Name:     "revocationStatus",
+			Aliases:  []string{"ocsp", "crl", "checkRevocation"},
+			Help:     "Check a certificate's revocation status with OCSP and CRLs",
+			LongHelp: `TODO(@cpu): Write this!`,
+			Func:     revocationStatusHandler,
+		},
+		nil)
+}
+
+type revocationStatusOptions struct {
+	orderIndex int
+	pemPath    string
+	issuerPath string
+	ocspURL    string
+	crlURL     string
+	checkOCSP  bool
+	checkCRL   bool
+}
+
+func revocationStatusHandler(c *ishell.Context) {
+	opts := revocationStatusOptions{}
+	revocationStatusFlags := flag.NewFlagSet("revocationStatus", flag.ContinueOnError)
+	revocationStatusFlags.IntVar(&opts.orderIndex, "order", -1, "index of order with the certificate to check")
+	revocationStatusFlags.StringVar(&opts.pemPath, "path", "", "file path of a PEM certificate (optionally followed by its issuer) to check")
+	revocationStatusFlags.StringVar(&opts.issuerPath, "issuer", "", "file path of the PEM issuer certificate (default: from the chain or the AIA caIssuers URL)")
+	revocationStatusFlags.StringVar(&opts.ocspURL, "ocspURL", "", "OCSP responder URL to use instead of the certificate's AIA OCSP server")
+	revocationStatusFlags.StringVar(&opts.crlURL, "crlURL", "", "CRL URL to use instead of the certificate's CRL distribution point")
+	revocationStatusFlags.BoolVar(&opts.checkOCSP, "ocsp", true, "check the revocation status with OCSP")
+	revocationStatusFlags.BoolVar(&opts.checkCRL, "crl", true, "check the revocation status with the CRL")
+
+	leftovers, err := commands.ParseFlags(c, revocationStatusFlags)
+	if err != nil {
+		return
+	}
+
+	if !opts.checkOCSP && !opts.checkCRL {
+		commands.Failf(c, "revocationStatus: one of -ocsp or -crl must be true\n")
+		return
+	}
+
+	if opts.pemPath != "" && (len(leftovers) > 0 || opts.orderIndex != -1) {
+		commands.Failf(c, "revocationStatus: -path is mutually exclusive with -order or an order URL\n")
+		return
+	}
+
+	client := commands.GetClient(c)
+
+	var certs []*x509.Certificate
+	if opts.pemPath != "" {
+		certs, err = acmeclient.ReadCertificates(opts.pemPath)
+		if err != nil {
+			commands.Failf(c, "revocationStatus: error loading certificate from %q: %v\n", opts.pemPath, err)
+			return
+		}
+	} else {
+		orderURL, err := commands.FindOrderURL(c, leftovers, opts.orderIndex)
+		if err != nil {
+			commands.Failf(c, "revocationStatus: error getting order URL: %v\n", err)
+			return
+		}
+		order := &resources.Order{
+			ID: orderURL,
+		}
+		if err := client.UpdateOrder(order); err != nil {
+			commands.Failf(c, "revocationStatus: error getting order: %v\n", err)
+			return
+		}
+		chains, err := client.GetCertificateChains(order)
+		if err != nil {
+			commands.Failf(c, "revocationStatus: error getting certificate: %v\n", err)
+			return
+		}
+		certs = chains[0].Certs
+	}
+	cert := certs[0]
+
+	var issuer *x509.Certificate
+	switch {
+	case opts.issuerPath != "":
+		issuers, err := acmeclient.ReadCertificates(opts.issuerPath)
+		if err != nil {
+			commands.Failf(c, "revocationStatus: error loading issuer from %q: %v\n", opts.issuerPath, err)
+			return
+		}
+		issuer = issuers[0]
+	case len(certs) > 1:
+		issuer = certs[1]
+	default:
+		issuer, err = client.FetchIssuer(cert)
+		if err != nil {
+			commands.Failf(c, "revocationStatus: error finding issuer: %v\n", err)
+			return
+		}
+	}
+	if err := cert.CheckSignatureFrom(issuer); err != nil {
+		commands.Failf(c, "revocationStatus: %q did not issue the certificate: %v\n", issuer.Subject, err)
+		return
+	}
+
+	c.Printf("Certificate serial %x issued by %s\n", cert.SerialNumber, issuer.Subject)
+	report := revocationReport{
+		Serial: fmt.Sprintf("%x", cert.SerialNumber),
+		Issuer: issuer.Subject.String(),
+	}
+	commands.SetResource(c, &report)
+
+	if opts.checkOCSP {
+		status, err := client.CheckOCSP(cert, issuer, opts.ocspURL)
+		if err != nil {
+			commands.Failf(c, "OCSP: error: %v\n", err)
+		} else {
+			printStatus(c, "OCSP", status)
+			report.OCSP = status
+			commands.SetStatus(c, status.Status)
+		}
+	}
+
+	if opts.checkCRL {
+		status, err := client.CheckCRL(cert, issuer, opts.crlURL)
+		if err != nil {
+			commands.Failf(c, "CRL: error: %v\n", err)
+		} else {
+			printStatus(c, "CRL", status)
+			report.CRL = status
+			if report.OCSP == nil {
+				commands.SetStatus(c, status.Status)
+			}
+		}
+	}
+}
+
+// revocationReport is the result reported by the revocationStatus command.
+type revocationReport struct {
+	Serial string                       `json:"serial"`
+	Issuer string                       `json:"issuer"`
+	OCSP   *acmeclient.RevocationStatus `json:"ocsp,omitempty"`
+	CRL    *acmeclient.RevocationStatus `json:"crl,omitempty"`
+}
+
+func printStatus(c *ishell.Context, source string, status *acmeclient.RevocationStatus) {
+	c.Printf("%s: %s (from %q)\n", source, status.Status, status.URL)
+	if status.Status == "revoked" {
+		c.Printf("  Revoked At: %s\n", status.RevokedAt.UTC().Format(time.RFC3339))
+		c.Printf("  Reason: %s (%d)\n",
+			acmeclient.RevocationReasonName(status.Reason), status.Reason)
+	}
+	c.Printf("  This Update: %s\n", status.ThisUpdate.UTC().Format(time.RFC3339))
+	if !status.NextUpdate.IsZero() {
+		c.Printf("  Next Update: %s\n", status.NextUpdate.UTC().Format(time.RFC3339))
+	}
+}
@@ -1,17 +1,16 @@
 package revokeCert
 
 import (
-	"encoding/base64"
-	"encoding/json"
-	"encoding/pem"
+	"crypto/x509"
 	"flag"
-	"net/http"
+	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/abiosoft/ishell"
 	acmeclient "github.com/cpu/acmeshell/acme/client"
 	"github.com/cpu/acmeshell/acme/resources"
-	"github.com/cpu/acmeshell/net"
 	"github.com/cpu/acmeshell/shell/commands"
 )
 
@@ -20,7 +19,7 @@ func init() {
 		&ishell.Cmd{
 			Name:     "revokeCert",
 			Aliases:  []string{"revokeCertificate", "revoke"},
-			Help:     "TODO: Describe the revokeCert command",
+			Help:     "Revoke a certificate",
 			LongHelp: "TODO: Describe the revokeCert command (long)",
 			Func:     revokeCertHandler,
 		},
@@ -28,10 +27,20 @@ func init() {
 }
 
 type revokeOptions struct {
-	orderIndex int
-	keyID      string
-	certPEM    string
-	reason     int
+	orderIndex   int
+	keyID        string
+	certPEM      string
+	reason       string
+	accountIndex int
+	checkAuthz   bool
+	all          bool
+}
+
+// revocationTarget is a certificate to revoke and a description of where it
+// came from.
+type revocationTarget struct {
+	cert   *x509.Certificate
+	source string
 }
 
 func revokeCertHandler(c *ishell.Context) {
@@ -40,128 +49,225 @@ func revokeCertHandler(c *ishell.Context) {
 	revokeFlags.IntVar(&opts.orderIndex, "order", -1, "index of order to revoke")
 	revokeFlags.StringVar(&opts.keyID, "keyID", "", "Key ID to use for embedded JWK revocation")
 	revokeFlags.StringVar(&opts.certPEM, "certPEM", "", "Path to PEM Certificate file to revoke")
-	// TODO(@cpu): Consider parsing string names for codes from
-	// https://tools.ietf.org/html/rfc5280#section-5.3.1
-	revokeFlags.IntVar(&opts.reason, "reason", 1, "Revocation reason code, see https://tools.ietf.org/html/rfc5280#section-5.3.1")
+	revokeFlags.StringVar(&opts.reason, "reason", "keyCompromise",
+		"Revocation reason name or code: "+strings.Join(acmeclient.RevocationReasonNames(), ", ")+
+			". See https://tools.ietf.org/html/rfc5280#section-5.3.1")
+	revokeFlags.IntVar(&opts.accountIndex, "account", -1, "index of a different account holding valid authorizations for every certificate name to revoke with")
+	revokeFlags.BoolVar(&opts.checkAuthz, "checkAuthz", true, "with -account, check the account's authorizations cover every certificate name before revoking")
+	revokeFlags.BoolVar(&opts.all, "all", false, "revoke every valid certificate across the active account's orders")
 
-	leftovers, err := commands.ParseFlagSetArgs(c.Args, revokeFlags)
+	leftovers, err := commands.ParseFlags(c, revokeFlags)
 	if err != nil {
 		return
 	}
 
-	client := commands.GetClient(c)
-
-	revokeURL, ok := client.GetEndpointURL("revokeCert")
-	if !ok {
-		c.Printf("revokeCert: no revokeCert endpoint in server's directory response\n")
+	reason, err := acmeclient.ParseRevocationReason(opts.reason)
+	if err != nil {
+		commands.Failf(c, "revokeCert: %v\n", err)
 		return
 	}
+	if warning := acmeclient.RevocationReasonWarning(reason); warning != "" {
+		c.Printf("revokeCert: warning: %s\n", warning)
+	}
 
 	if opts.certPEM != "" && (len(leftovers) > 0 || opts.orderIndex != -1) {
-		c.Printf("revokeCert: -certPEM is mutually exclusive with -orderIndex or a cert URL\n")
+		commands.Failf(c, "revokeCert: -certPEM is mutually exclusive with -order or an order URL\n")
+		return
+	}
+	if opts.all && (opts.certPEM != "" || len(leftovers) > 0 || opts.orderIndex != -1) {
+		commands.Failf(c, "revokeCert: -all is mutually exclusive with -certPEM, -order or an order URL\n")
+		return
+	}
+	if opts.all && opts.keyID != "" {
+		commands.Failf(c, "revokeCert: -all is mutually exclusive with -keyID\n")
+		return
+	}
+	if opts.keyID != "" && opts.accountIndex != -1 {
+		commands.Failf(c, "revokeCert: -keyID and -account are mutually exclusive\n")
 		return
 	}
 
-	var pemBytes []byte
-	// TODO(@cpu): There should be a higher level GetCertificate function on the
-	// client that this and the getCert command can share.
-	if opts.certPEM == "" {
-		orderURL, err := commands.FindOrderURL(c, leftovers, opts.orderIndex)
-		if err != nil {
-			c.Printf("revokeCert: error getting order URL: %v\n", err)
+	client := commands.GetClient(c)
+
+	var signOpts *acmeclient.SigningOptions
+	if opts.keyID != "" {
+		key, found := client.Keys[opts.keyID]
+		if !found {
+			commands.Failf(c, "revokeCert: no key with ID %q exists in shell\n", opts.keyID)
 			return
 		}
+		// If there was a key ID specified then we want to embed that key as the JWK
+		// authorizing the revocation request.
+		signOpts = &acmeclient.SigningOptions{
+			EmbedKey: true,
+			Signer:   key,
+		}
+	}
 
-		order := &resources.Order{
-			ID: orderURL,
+	var revoker *resources.Account
+	if opts.accountIndex != -1 {
+		if opts.accountIndex < 0 || opts.accountIndex >= len(client.Accounts) {
+			commands.Failf(c, "revokeCert: -account index must be 0 <= x < %d\n", len(client.Accounts))
+			return
 		}
-		err = client.UpdateOrder(order)
-		if err != nil {
-			c.Printf("revokeCert: error getting order: %s\n", err.Error())
+		revoker = client.Accounts[opts.accountIndex]
+		if revoker.ID == "" {
+			commands.Failf(c, "revokeCert: -account %d has not been created\n", opts.accountIndex)
 			return
 		}
+		if revoker.ID == client.ActiveAccountID() {
+			c.Printf("revokeCert: warning: -account %d is the active account\n", opts.accountIndex)
+		}
+		// Sign the revocation requests with the revoking account's key and ID.
+		signOpts = &acmeclient.SigningOptions{
+			KeyID:  revoker.ID,
+			Signer: revoker.Signer,
+		}
+	}
 
-		if order.Status != "valid" {
-			c.Printf("revokeCert: order %q is status %q, not \"valid\"\n", order.ID, order.Status)
+	var targets []revocationTarget
+	switch {
+	case opts.certPEM != "":
+		target, err := certFileTarget(opts.certPEM)
+		if err != nil {
+			commands.Failf(c, "revokeCert: %v\n", err)
 			return
 		}
-
-		if order.Certificate == "" {
-			c.Printf("revokeCert: order %q has no Certificate URL\n", order.ID)
+		targets = append(targets, target)
+	case opts.all:
+		targets, err = allOrderTargets(c, client)
+		if err != nil {
+			commands.Failf(c, "revokeCert: %v\n", err)
 			return
 		}
-
-		var resp *net.NetResponse
-		if client.PostAsGet {
-			resp, err = client.PostAsGetURL(order.Certificate)
-		} else {
-			resp, err = client.GetURL(order.Certificate)
+		if len(targets) == 0 {
+			commands.Failf(c, "revokeCert: active account has no valid certificates to revoke\n")
+			return
 		}
+	default:
+		orderURL, err := commands.FindOrderURL(c, leftovers, opts.orderIndex)
 		if err != nil {
-			c.Printf("revokeCert: failed to GET order certificate URL %q : %v\n", order.Certificate, err)
+			commands.Failf(c, "revokeCert: error getting order URL: %v\n", err)
 			return
 		}
-		respOb := resp.Response
-		if respOb.StatusCode != http.StatusOK {
-			c.Printf("revokeCert: failed to GET order certificate URL %q . Status code: %d\n", order.Certificate, respOb.StatusCode)
-			c.Printf("revokeCert: response body: %s\n", resp.RespBody)
+		order := &resources.Order{
+			ID: orderURL,
+		}
+		if err := client.UpdateOrder(order); err != nil {
+			commands.Failf(c, "revokeCert: error getting order: %v\n", err)
 			return
 		}
-
-		pemBytes = resp.RespBody
-	} else {
-		fileBytes, err := os.ReadFile(opts.certPEM)
+		target, err := orderTarget(client, order)
 		if err != nil {
-			c.Printf("revokeCert: error reading -certPEM argument: %q\n", err)
+			commands.Failf(c, "revokeCert: %v\n", err)
 			return
 		}
-		pemBytes = fileBytes
+		targets = append(targets, target)
 	}
-	pemBlock, _ := pem.Decode(pemBytes)
-	certBytes := pemBlock.Bytes
 
-	revokeRequest := struct {
-		Certificate string `json:"certificate"`
-		Reason      int    `json:"reason"`
-	}{
-		Certificate: base64.RawURLEncoding.EncodeToString(certBytes),
-		Reason:      opts.reason,
+	if opts.keyID != "" {
+		for _, target := range targets {
+			if !acmeclient.PublicKeyMatches(target.cert, signOpts.Signer) {
+				c.Printf("revokeCert: warning: key %q is not the key of %s\n", opts.keyID, target.source)
+			}
+		}
 	}
-	revokeRequestJSON, _ := json.Marshal(&revokeRequest)
 
-	signOpts := &acmeclient.SigningOptions{}
+	if revoker != nil {
+		c.Printf("Revoking with account %q\n", revoker.ID)
 
-	if opts.keyID != "" {
-		if key, found := client.Keys[opts.keyID]; found {
-			// If there was a key ID specified then we want to embed that key as the JWK
-			// authorizing the revocation request.
-			signOpts.EmbedKey = true
-			signOpts.Signer = key
-		}
-		if signOpts.Signer == nil {
-			c.Printf("revokeCert: no key with ID %q exists in shell\n", opts.keyID)
-			return
+		if opts.checkAuthz {
+			identifiers, err := client.AuthorizedIdentifiers(revoker)
+			if err != nil {
+				commands.Failf(c, "revokeCert: error checking authorizations of account %q: %v\n", revoker.ID, err)
+				return
+			}
+			for _, target := range targets {
+				if missing := acmeclient.UnauthorizedNames(target.cert, identifiers); len(missing) > 0 {
+					c.Printf("revokeCert: warning: account %q has no valid authorization for %s of %s\n",
+						revoker.ID, strings.Join(missing, ", "), target.source)
+				}
+			}
 		}
 	}
 
-	signResult, err := client.Sign(revokeURL, revokeRequestJSON, signOpts)
-	if err != nil {
-		c.Printf("revokeCert: failed to sign revocation request: %v\n", err)
-		return
+	var revoked int
+	for _, target := range targets {
+		c.Printf("Revoking %s (serial %x) with reason %s (%d)\n",
+			target.source, target.cert.SerialNumber, acmeclient.RevocationReasonName(reason), reason)
+		if err := client.RevokeCertificate(target.cert, reason, signOpts); err != nil {
+			commands.Failf(c, "revokeCert: failed to revoke %s: %v\n", target.source, err)
+			continue
+		}
+		revoked++
+	}
+
+	if len(targets) == 1 && revoked == 1 {
+		c.Printf("Successfully revoked certificate\n")
+	} else if len(targets) > 1 {
+		c.Printf("Successfully revoked %d of %d certificates\n", revoked, len(targets))
 	}
+}
 
-	c.Printf("POSTing %q to revoke certificate\n", revokeURL)
-	resp, err := client.PostURL(revokeURL, signResult.SerializedJWS)
+// certFileTarget returns the first certificate in the given PEM file.
+func certFileTarget(path string) (revocationTarget, error) {
+	pemBytes, err := os.ReadFile(path)
 	if err != nil {
-		c.Printf("revokeCert: POST request failed: %v\n", err)
-		return
+		return revocationTarget{}, fmt.Errorf("error reading -certPEM argument: %w", err)
+	}
+	certs, err := acmeclient.ParseCertificates(pemBytes)
+	if err != nil {
+		return revocationTarget{}, fmt.Errorf("error parsing -certPEM argument: %w", err)
 	}
+	return revocationTarget{
+		cert:   certs[0],
+		source: fmt.Sprintf("certificate from %q", path),
+	}, nil
+}
 
-	respOb := resp.Response
-	if respOb.StatusCode != http.StatusOK {
-		c.Printf("revokeCert: POST request failed. Status code: %d\n", respOb.StatusCode)
-		return
+// orderTarget returns the certificate of the given order.
+func orderTarget(client *acmeclient.Client, order *resources.Order) (revocationTarget, error) {
+	pemBytes, err := client.GetCertificate(order)
+	if err != nil {
+		return revocationTarget{}, err
+	}
+	certs, err := acmeclient.ParseCertificates(pemBytes)
+	if err != nil {
+		return revocationTarget{}, fmt.Errorf("error parsing certificate of order %q: %w", order.ID, err)
 	}
+	return revocationTarget{
+		cert:   certs[0],
+		source: fmt.Sprintf("certificate of order %q", order.ID),
+	}, nil
+}
 
-	c.Printf("Successfully revoked certificate\n")
+// allOrderTargets returns the unexpired certificates of every valid order of
+// the active account.
+func allOrderTargets(c *ishell.Context, client *acmeclient.Client) ([]revocationTarget, error) {
+	if client.ActiveAccountID() == "" {
+		return nil, fmt.Errorf("active account is nil or has not been created")
+	}
+	var targets []revocationTarget
+	now := time.Now()
+	for _, orderURL := range client.ActiveAccount.Orders {
+		order := &resources.Order{
+			ID: orderURL,
+		}
+		if err := client.UpdateOrder(order); err != nil {
+			return nil, fmt.Errorf("error getting order %q: %w", orderURL, err)
+		}
+		if order.Status != "valid" || order.Certificate == "" {
+			continue
+		}
+		target, err := orderTarget(client, order)
+		if err != nil {
+			return nil, err
+		}
+		if target.cert.NotAfter.Before(now) {
+			c.Printf("Skipping expired %s\n", target.source)
+			continue
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
 }
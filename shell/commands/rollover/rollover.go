@@ -30,18 +30,18 @@ func rolloverHandler(c *ishell.Context) {
 	keyRolloverFlags := flag.NewFlagSet("keyRollover", flag.ContinueOnError)
 	keyRolloverFlags.StringVar(&opts.keyID, "keyID", "", "Key ID to rollover to (leave empty to select interactively)")
 
-	if _, err := commands.ParseFlagSetArgs(c.Args, keyRolloverFlags); err != nil {
+	if _, err := commands.ParseFlags(c, keyRolloverFlags); err != nil {
 		return
 	}
 
 	client := commands.GetClient(c)
 
 	if len(client.Keys) == 0 {
-		c.Printf("No keys known to shell to rollover to\n")
+		commands.Failf(c, "No keys known to shell to rollover to\n")
 		return
 	}
 	if len(client.Keys) == 1 {
-		c.Printf("Only the active key is known to the shell. No other key to rollover to\n")
+		commands.Failf(c, "Only the active key is known to the shell. No other key to rollover to\n")
 		return
 	}
 
@@ -64,12 +64,14 @@ func rolloverHandler(c *ishell.Context) {
 			newKey = k
 		}
 		if newKey == nil {
-			c.Printf("No key with ID %q known to shell\n", opts.keyID)
+			commands.Failf(c, "No key with ID %q known to shell\n", opts.keyID)
 			return
 		}
 	}
 
 	if err := client.Rollover(newKey); err != nil {
-		c.Printf("keyRollover: %v\n", err)
+		commands.Failf(c, "keyRollover: %v\n", err)
+		return
 	}
+	commands.SetResource(c, client.ActiveAccount)
 }
@@ -29,7 +29,7 @@ func saveAccountHandler(c *ishell.Context) {
 	saveAccountFlags := flag.NewFlagSet("saveAccount", flag.ContinueOnError)
 	saveAccountFlags.StringVar(&opts.jsonPath, "json", "", "Filepath to a JSON save file for the account. If empty the -account argument is used")
 
-	if _, err := commands.ParseFlagSetArgs(c.Args, saveAccountFlags); err != nil {
+	if _, err := commands.ParseFlags(c, saveAccountFlags); err != nil {
 		return
 	}
 
@@ -37,7 +37,7 @@ func saveAccountHandler(c *ishell.Context) {
 
 	acct := client.ActiveAccount
 	if acct == nil {
-		c.Printf("no active account to save")
+		commands.Failf(c, "no active account to save")
 		return
 	}
 
@@ -47,12 +47,12 @@ func saveAccountHandler(c *ishell.Context) {
 	}
 
 	if jsonPath == "" {
-		c.Printf("no -json path provided and active account has no default path.")
+		commands.Failf(c, "no -json path provided and active account has no default path.")
 		return
 	}
 
 	if err := resources.SaveAccount(jsonPath, acct); err != nil {
-		c.Printf("error saving account to %q : %v\n", jsonPath, err)
+		commands.Failf(c, "error saving account to %q : %v\n", jsonPath, err)
 		return
 	}
 
@@ -38,31 +38,31 @@ func signHandler(c *ishell.Context) {
 	signFlags.BoolVar(&opts.noData, "noData", false, "Use an empty byteslice as the data to sign (e.g. POST-as-GET)")
 	signFlags.BoolVar(&opts.templateURL, "templateURL", true, "Evaluate URL as a template")
 
-	leftovers, err := commands.ParseFlagSetArgs(c.Args, signFlags)
+	leftovers, err := commands.ParseFlags(c, signFlags)
 	if err != nil {
 		return
 	}
 
 	if len(leftovers) < 1 {
-		c.Printf("sign: you must specify a URL for the JWS header\n")
+		commands.Failf(c, "sign: you must specify a URL for the JWS header\n")
 		return
 	}
 
 	client := commands.GetClient(c)
 	url, err := commands.FindURL(client, leftovers)
 	if err != nil {
-		c.Printf("sign: error finding URL: %v", err)
+		commands.Failf(c, "sign: error finding URL: %v", err)
 		return
 	}
 
 	if url == "" {
-		c.Printf("sign: you must specify a non-empty URL for the JWS header\n")
+		commands.Failf(c, "sign: you must specify a non-empty URL for the JWS header\n")
 		return
 	}
 
 	// Check the URL and make sure it is valid-ish
 	if !commands.OkURL(url) {
-		c.Printf("sign: illegal url argument %q\n", url)
+		commands.Failf(c, "sign: illegal url argument %q\n", url)
 		return
 	}
 
@@ -70,7 +70,7 @@ func signHandler(c *ishell.Context) {
 	// use the trimmed value as the data
 	if trimmedData := strings.TrimSpace(opts.dataString); trimmedData != "" {
 		if opts.noData {
-			c.Printf("sign: using -noData and providing a -data value are mutually exclusive\n")
+			commands.Failf(c, "sign: using -noData and providing a -data value are mutually exclusive\n")
 			return
 		}
 		opts.data = []byte(trimmedData)
@@ -90,7 +90,7 @@ func signData(c *ishell.Context, targetURL string, opts signCmdOptions) {
 	account := client.ActiveAccount
 
 	if account == nil && opts.keyID == "" {
-		c.Printf("sign: no active ACME account to sign data with\n")
+		commands.Failf(c, "sign: no active ACME account to sign data with\n")
 		return
 	}
 
@@ -106,16 +106,18 @@ func signData(c *ishell.Context, targetURL string, opts signCmdOptions) {
 			}
 		}
 		if signOpts.Signer == nil {
-			c.Printf("sign: no key with ID %q exists in shell\n", opts.keyID)
+			commands.Failf(c, "sign: no key with ID %q exists in shell\n", opts.keyID)
 			return
 		}
 	}
 
 	signResult, err := client.Sign(targetURL, opts.data, signOpts)
 	if err != nil {
-		c.Printf("sign: error signing data: %s\n", err)
+		commands.Failf(c, "sign: error signing data: %s\n", err)
 		return
 	}
 
+	commands.SetResource(c, signResult.SerializedJWS)
+	commands.SetURL(c, targetURL)
 	c.Printf("signed JWS for URL %q: \n%s\n", targetURL, signResult.SerializedJWS)
 }
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/abiosoft/ishell"
+	acmeclient "github.com/cpu/acmeshell/acme/client"
 	"github.com/cpu/acmeshell/acme/keys"
 	"github.com/cpu/acmeshell/acme/resources"
 	"github.com/cpu/acmeshell/shell/commands"
@@ -27,6 +28,7 @@ type solveOptions struct {
 	printKeyAuthorization bool
 	printToken            bool
 	orderIndex            int
+	authzIndex            int
 	identifier            string
 	challType             string
 }
@@ -39,32 +41,28 @@ func solveHandler(c *ishell.Context) {
 	solveFlags.StringVar(&opts.challType, "challengeType", "", "Challenge type to solve")
 	solveFlags.StringVar(&opts.identifier, "identifier", "", "Authorization identifier to solve for")
 	solveFlags.IntVar(&opts.orderIndex, "order", -1, "index of existing order")
+	solveFlags.IntVar(&opts.authzIndex, "authz", -1, "index of existing standalone authorization (see newAuthz)")
 
-	leftovers, err := commands.ParseFlagSetArgs(c.Args, solveFlags)
+	leftovers, err := commands.ParseFlags(c, solveFlags)
 	if err != nil {
 		return
 	}
 
 	client := commands.GetClient(c)
-	challSrv := commands.GetChallSrv(c)
+	responses := commands.GetResponseTracker(c)
 
 	var targetURL string
 	if len(leftovers) > 0 {
 		templateText := strings.Join(leftovers, " ")
 		targetURL, err = commands.ClientTemplate(client, templateText)
 		if err != nil {
-			c.Printf("solve: error templating order URL: %v\n", err)
+			commands.Failf(c, "solve: error templating order URL: %v\n", err)
 			return
 		}
 	} else {
-		targetURL, err = commands.FindOrderURL(c, nil, opts.orderIndex)
+		targetURL, err = commands.FindAnyAuthzURL(c, opts.orderIndex, opts.authzIndex, opts.identifier)
 		if err != nil {
-			c.Printf("solve: error getting order URL: %v\n", err)
-			return
-		}
-		targetURL, err = commands.FindAuthzURL(c, targetURL, opts.identifier)
-		if err != nil {
-			c.Printf("solve: error getting authz URL: %v\n", err)
+			commands.Failf(c, "solve: error getting authz URL: %v\n", err)
 			return
 		}
 	}
@@ -74,7 +72,7 @@ func solveHandler(c *ishell.Context) {
 	}
 	err = client.UpdateAuthz(authz)
 	if err != nil {
-		c.Printf("solve: error getting authorization object from %q: %v\n", targetURL, err)
+		commands.Failf(c, "solve: error getting authorization object from %q: %v\n", targetURL, err)
 		return
 	}
 
@@ -87,7 +85,7 @@ func solveHandler(c *ishell.Context) {
 			}
 		}
 		if chall == nil {
-			c.Printf("solve: authz %q has no %q type challenge\n",
+			commands.Failf(c, "solve: authz %q has no %q type challenge\n",
 				authz.ID, opts.challType)
 			return
 		}
@@ -95,7 +93,7 @@ func solveHandler(c *ishell.Context) {
 		var err error
 		chall, err = commands.PickChall(c, authz)
 		if err != nil {
-			c.Printf("solve: error picking challenge: %v\n", err)
+			commands.Failf(c, "solve: error picking challenge: %v\n", err)
 			return
 		}
 	}
@@ -110,35 +108,30 @@ func solveHandler(c *ishell.Context) {
 		c.Printf("key authorization:\n%s\n", keyAuth)
 	}
 
-	switch strings.ToUpper(chall.Type) {
-	case "HTTP-01":
-		challSrv.AddHTTPOneChallenge(token, keyAuth)
-	case "DNS-01":
-		challSrv.AddDNSOneChallenge(authz.Identifier.Value, keyAuth)
-	case "TLS-ALPN-01":
-		challSrv.AddTLSALPNChallenge(authz.Identifier.Value, keyAuth)
-	default:
-		c.Printf("challenge %q has unknown type: %q\n", chall.URL, chall.Type)
+	response, err := commands.ChallengeResponse(client, authz, chall)
+	if err != nil {
+		commands.Failf(c, "solve: %v\n", err)
 		return
 	}
-	c.Printf("Challenge response ready\n")
-
-	signResult, err := client.Sign(chall.URL, []byte("{}"), nil)
-	if err != nil {
-		c.Printf("solve: failed to sign challenge POST body: %s\n", err.Error())
+	if err := responses.Publish(response); err != nil {
+		commands.Failf(c, "solve: error publishing challenge response: %v\n", err)
 		return
 	}
+	if response.ChallengeType == "dns-account-01" {
+		c.Printf("Published dns-account-01 TXT record %q\n", response.Key)
+	}
+	c.Printf("Challenge response ready\n")
 
-	resp, err := client.PostURL(chall.URL, signResult.SerializedJWS)
+	resp, err := client.PostSignedURL(chall.URL, []byte("{}"), nil)
 	if err != nil {
-		c.Printf("solve: failed to POST challenge %q: %v\n", chall.URL, err)
+		commands.Failf(c, "solve: failed to POST challenge %q: %v\n", chall.URL, err)
 		return
 	}
-	respOb := resp.Response
-	if respOb.StatusCode != http.StatusOK {
-		c.Printf("solve: failed to POST %q challenge. Status code: %d\n", chall.URL, respOb.StatusCode)
-		c.Printf("solve: response body: %s\n", resp.RespBody)
+	if err := acmeclient.CheckResponse(resp, http.StatusOK); err != nil {
+		commands.Failf(c, "solve: failed to POST %q challenge: %v\n", chall.URL, err)
 		return
 	}
+	commands.SetResource(c, resp.RespBody)
+	commands.SetURL(c, chall.URL)
 	c.Printf("solve: %q challenge for identifier %q (%q) started\n", chall.Type, authz.Identifier.Value, chall.URL)
 }
@@ -30,7 +30,7 @@ func switchAccountHandler(c *ishell.Context) {
 	switchAccountFlags := flag.NewFlagSet("switchAccount", flag.ContinueOnError)
 	switchAccountFlags.IntVar(&opts.accountIndex, "account", -1, "account number to switch to. leave blank to pick interactively")
 
-	if _, err := commands.ParseFlagSetArgs(c.Args, switchAccountFlags); err != nil {
+	if _, err := commands.ParseFlags(c, switchAccountFlags); err != nil {
 		return
 	}
 
@@ -38,13 +38,14 @@ func switchAccountHandler(c *ishell.Context) {
 
 	if opts.accountIndex >= 0 {
 		if opts.accountIndex >= len(client.Accounts) {
-			c.Printf("switchAccount: provided account index (%d) "+
+			commands.Failf(c, "switchAccount: provided account index (%d) "+
 				"is larger than number of accounts (%d)\n",
 				opts.accountIndex, len(client.Accounts))
 			return
 		}
 
 		client.ActiveAccount = client.Accounts[opts.accountIndex]
+		commands.SetResource(c, client.ActiveAccount)
 		c.Printf("Active account is now #%d - %q\n", opts.accountIndex, client.ActiveAccount.ID)
 		return
 	}
@@ -65,5 +66,6 @@ func switchAccountHandler(c *ishell.Context) {
 	choice := c.MultiChoice(accountList, "Which account would you like to switch to?")
 
 	client.ActiveAccount = client.Accounts[choice]
+	commands.SetResource(c, client.ActiveAccount)
 	c.Printf("Active account is now #%d - %q\n", choice, client.ActiveAccount.ID)
 }
@@ -59,7 +59,7 @@ func (ctx TemplateCtx) authz(order *resources.Order, identifier string) (*resour
 			return nil, err
 		}
 
-		if authz.Identifier.Value == identifier {
+		if authz.Identifier.Matches(identifier) {
 			match = authz
 			break
 		}
@@ -111,8 +111,10 @@ func (ctx TemplateCtx) csr(order *resources.Order, privateKey crypto.Signer) (st
 		names[i] = ident.Value
 	}
 
+	dnsNames, ipAddresses := acmeclient.SANs(names)
 	template := x509.CertificateRequest{
-		DNSNames: names,
+		DNSNames:    dnsNames,
+		IPAddresses: ipAddresses,
 	}
 
 	if privateKey == nil {
@@ -127,6 +129,16 @@ func (ctx TemplateCtx) csr(order *resources.Order, privateKey crypto.Signer) (st
 	return base64.RawURLEncoding.EncodeToString(csrBytes), nil
 }
 
+func (ctx TemplateCtx) chains(order *resources.Order) ([]*acmeclient.CertificateChain, error) {
+	if order == nil {
+		return nil, fmt.Errorf("nil order argument")
+	}
+	if ctx.Client == nil {
+		return nil, fmt.Errorf("nil client in context")
+	}
+	return ctx.Client.GetCertificateChains(order)
+}
+
 func (ctx TemplateCtx) account() (*resources.Account, error) {
 	if ctx.Acct == nil {
 		return nil, fmt.Errorf("no active account")
@@ -159,6 +171,7 @@ func EvalTemplate(templateStr string, ctx TemplateCtx) (string, error) {
 		"privateKey":    ctx.key,
 		"csr":           ctx.csr,
 		"CSR":           ctx.csr,
+		"chains":        ctx.chains,
 	}
 
 	tmpl, err := template.New("input template").Funcs(funcMap).Parse(templateStr)
@@ -0,0 +1,108 @@
+package updateAccount
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/abiosoft/ishell"
+	"github.com/cpu/acmeshell/acme/resources"
+	"github.com/cpu/acmeshell/shell/commands"
+)
+
+func init() {
+	commands.RegisterCommand(
+		&ishell.Cmd{
+			Name:     "updateAccount",
+			Aliases:  []string{"updateAcct", "updateReg", "updateRegistration"},
+			Help:     "Update the active ACME account's contacts or terms of service agreement",
+			LongHelp: `TODO(@cpu): Write this!`,
+			Func:     updateAccountHandler,
+		},
+		nil)
+}
+
+type updateAccountOptions struct {
+	contacts      string
+	clearContacts bool
+	agreeTOS      bool
+	save          bool
+	jsonPath      string
+}
+
+func updateAccountHandler(c *ishell.Context) {
+	opts := updateAccountOptions{}
+	updateAccountFlags := flag.NewFlagSet("updateAccount", flag.ContinueOnError)
+	updateAccountFlags.StringVar(&opts.contacts, "contacts", "", "Comma separated list of contact URIs. Values without a URI scheme are treated as emails")
+	updateAccountFlags.BoolVar(&opts.clearContacts, "clearContacts", false, "Remove all of the account's contacts")
+	updateAccountFlags.BoolVar(&opts.agreeTOS, "agreeTOS", false, "Send termsOfServiceAgreed in the update request")
+	updateAccountFlags.BoolVar(&opts.save, "save", true, "Save the updated account to its JSON path (if any)")
+	updateAccountFlags.StringVar(&opts.jsonPath, "json", "", "Filepath to a JSON save file for the account. If empty the account's existing path is used")
+
+	if _, err := commands.ParseFlags(c, updateAccountFlags); err != nil {
+		return
+	}
+
+	if opts.clearContacts && opts.contacts != "" {
+		commands.Failf(c, "updateAccount: -contacts and -clearContacts are mutually exclusive\n")
+		return
+	}
+
+	var contacts []string
+	if opts.clearContacts {
+		contacts = []string{}
+	} else if opts.contacts != "" {
+		contacts = parseContacts(opts.contacts)
+	}
+
+	if contacts == nil && !opts.agreeTOS {
+		commands.Failf(c, "updateAccount: nothing to update. Provide -contacts, -clearContacts or -agreeTOS\n")
+		return
+	}
+
+	client := commands.GetClient(c)
+
+	acct := client.ActiveAccount
+	if acct == nil || acct.ID == "" {
+		commands.Failf(c, "updateAccount: no active account or active account not created\n")
+		return
+	}
+
+	if err := client.UpdateAccount(acct, contacts, opts.agreeTOS); err != nil {
+		commands.Failf(c, "updateAccount: error updating account with ACME server: %v\n", err)
+		return
+	}
+	commands.SetResource(c, acct)
+	c.Printf("Updated account %q Status %q Contacts %q\n", acct.ID, acct.Status, acct.Contact)
+
+	jsonPath := acct.Path()
+	if opts.jsonPath != "" {
+		jsonPath = opts.jsonPath
+	}
+	if !opts.save || jsonPath == "" {
+		return
+	}
+
+	if err := resources.SaveAccount(jsonPath, acct); err != nil {
+		commands.Failf(c, "updateAccount: error saving account to %q : %v\n", jsonPath, err)
+		return
+	}
+	c.Printf("Saved account data to %q\n", jsonPath)
+}
+
+// parseContacts splits a comma separated list of contacts into a slice of
+// contact URIs. Any contact without a URI scheme is assumed to be an email
+// address and is given a "mailto:" prefix.
+func parseContacts(raw string) []string {
+	var contacts []string
+	for _, contact := range strings.Split(raw, ",") {
+		contact = strings.TrimSpace(contact)
+		if contact == "" {
+			continue
+		}
+		if !strings.Contains(contact, ":") {
+			contact = "mailto:" + contact
+		}
+		contacts = append(contacts, contact)
+	}
+	return contacts
+}
@@ -0,0 +1,240 @@
+package verifyCert
+
+import (
+	"crypto/x509"
+	"errors"
+	"flag"
+
+	"github.com/abiosoft/ishell"
+	acmeclient "github.com/cpu/acmeshell/acme/client"
+	"github.com/cpu/acmeshell/acme/resources"
+	"github.com/cpu/acmeshell/shell/commands"
+)
+
+func init() {
+	commands.RegisterCommand(
+		&ishell.Cmd{
+			Name:     "verifyCert",
+			Aliases:  []string{"verifyCertificate", "verifyChain"},
+			Help:     "Verify an order's certificate chain builds to a trusted root",
+			LongHelp: `TODO(@cpu): Write this!`,
+			Func:     verifyCertHandler,
+		},
+		nil)
+}
+
+type verifyCertOptions struct {
+	pemPath           string
+	orderIndex        int
+	chainIndex        int
+	rootsPath         string
+	intermediatesPath string
+	useCABundle       bool
+	pebbleMgmtURL     string
+	fetchAIA          bool
+}
+
+func verifyCertHandler(c *ishell.Context) {
+	opts := verifyCertOptions{}
+	verifyCertFlags := flag.NewFlagSet("verifyCert", flag.ContinueOnError)
+	verifyCertFlags.StringVar(&opts.pemPath, "path", "", "file path of a PEM certificate chain to verify instead of an order's certificate")
+	verifyCertFlags.IntVar(&opts.orderIndex, "order", -1, "index of existing order")
+	verifyCertFlags.IntVar(&opts.chainIndex, "chain", 0, "index of the order's certificate chain to verify")
+	verifyCertFlags.StringVar(&opts.rootsPath, "roots", "", "file path of PEM root certificates to trust")
+	verifyCertFlags.StringVar(&opts.intermediatesPath, "intermediates", "", "file path of PEM intermediate certificates that may be used to build the path")
+	verifyCertFlags.BoolVar(&opts.useCABundle, "ca", false, "trust the CA certificate(s) given to the shell with -ca")
+	verifyCertFlags.StringVar(&opts.pebbleMgmtURL, "pebble", "", "trust the roots (and use the intermediates) from the Pebble management API at this URL (e.g. https://localhost:15000)")
+	verifyCertFlags.BoolVar(&opts.fetchAIA, "aia", true, "fetch intermediates from AIA caIssuers URLs when the served chain is incomplete")
+
+	leftovers, err := commands.ParseFlags(c, verifyCertFlags)
+	if err != nil {
+		return
+	}
+
+	client := commands.GetClient(c)
+
+	verifyOpts := acmeclient.VerifyChainOptions{
+		FetchAIA: opts.fetchAIA,
+	}
+
+	roots, err := rootPool(client, opts)
+	if err != nil {
+		commands.Failf(c, "verifyCert: error loading roots: %v\n", err)
+		return
+	}
+	verifyOpts.Roots = roots
+	if roots == nil {
+		c.Printf("verifyCert: no roots specified, using the system roots\n")
+	}
+
+	if opts.intermediatesPath != "" {
+		intermediates, err := acmeclient.ReadCertificates(opts.intermediatesPath)
+		if err != nil {
+			commands.Failf(c, "verifyCert: error loading intermediates: %v\n", err)
+			return
+		}
+		verifyOpts.Intermediates = append(verifyOpts.Intermediates, intermediates...)
+	}
+	if opts.pebbleMgmtURL != "" {
+		intermediates, err := client.PebbleCertificates(opts.pebbleMgmtURL, "intermediates")
+		if err != nil {
+			commands.Failf(c, "verifyCert: error fetching Pebble intermediates: %v\n", err)
+			return
+		}
+		verifyOpts.Intermediates = append(verifyOpts.Intermediates, intermediates...)
+	}
+
+	// A PEM file is only checked against an order if one was explicitly chosen.
+	var order *resources.Order
+	if opts.pemPath == "" || opts.orderIndex != -1 || len(leftovers) > 0 {
+		targetURL, err := commands.FindOrderURL(c, leftovers, opts.orderIndex)
+		if err != nil {
+			commands.Failf(c, "verifyCert: error getting order URL: %v\n", err)
+			return
+		}
+		order = &resources.Order{
+			ID: targetURL,
+		}
+		if err := client.UpdateOrder(order); err != nil {
+			commands.Failf(c, "verifyCert: error getting order: %v\n", err)
+			return
+		}
+		verifyOpts.Identifiers = order.Identifiers
+	}
+
+	var certs []*x509.Certificate
+	if opts.pemPath != "" {
+		certs, err = acmeclient.ReadCertificates(opts.pemPath)
+		if err != nil {
+			commands.Failf(c, "verifyCert: error loading certificate chain: %v\n", err)
+			return
+		}
+	} else {
+		chains, err := client.GetCertificateChains(order)
+		if err != nil {
+			commands.Failf(c, "verifyCert: error getting certificate chains: %v\n", err)
+			return
+		}
+		if opts.chainIndex < 0 || opts.chainIndex >= len(chains) {
+			commands.Failf(c, "verifyCert: -chain index must be 0 <= x < %d\n", len(chains))
+			return
+		}
+		c.Printf("Verifying certificate chain %q\n", chains[opts.chainIndex].URL)
+		certs = chains[opts.chainIndex].Certs
+	}
+
+	result, err := client.VerifyChain(certs, verifyOpts)
+	if result == nil {
+		commands.Failf(c, "verifyCert: %v\n", err)
+		return
+	}
+
+	report := verifyReport{
+		Path:                 subjects(result.Path),
+		MissingIntermediates: subjects(result.MissingIntermediates),
+		UnusedCertificates:   subjects(result.UnusedCertificates),
+	}
+
+	var failedIdentifiers int
+	for _, check := range result.Hostnames {
+		if check.Err != nil {
+			failedIdentifiers++
+			c.Printf("Identifier %q: FAILED: %v\n", check.Identifier.Value, check.Err)
+		} else {
+			c.Printf("Identifier %q: OK\n", check.Identifier.Value)
+		}
+		checked := identifierCheck{
+			Identifier: check.Identifier,
+			OK:         check.Err == nil,
+		}
+		if check.Err != nil {
+			checked.Error = check.Err.Error()
+		}
+		report.Identifiers = append(report.Identifiers, checked)
+	}
+	commands.SetResource(c, report)
+
+	if err != nil {
+		commands.Failf(c, "Path: FAILED: %v\n", err)
+		return
+	}
+
+	c.Printf("Path: OK\n")
+	for i, cert := range result.Path {
+		c.Printf("  %d) %s\n", i, cert.Subject)
+	}
+	for _, cert := range result.MissingIntermediates {
+		c.Printf("Missing intermediate (not in served chain): %s\n", cert.Subject)
+	}
+	for _, cert := range result.UnusedCertificates {
+		c.Printf("Unused certificate (served but not in path): %s\n", cert.Subject)
+	}
+	if failedIdentifiers > 0 {
+		commands.Failf(c, "verifyCert: certificate is not valid for %d identifier(s)\n", failedIdentifiers)
+	}
+}
+
+// verifyReport is the result reported by the verifyCert command.
+type verifyReport struct {
+	Path                 []string          `json:"path,omitempty"`
+	MissingIntermediates []string          `json:"missingIntermediates,omitempty"`
+	UnusedCertificates   []string          `json:"unusedCertificates,omitempty"`
+	Identifiers          []identifierCheck `json:"identifiers,omitempty"`
+}
+
+// identifierCheck is the result of checking the leaf certificate against one
+// identifier.
+type identifierCheck struct {
+	Identifier resources.Identifier `json:"identifier"`
+	OK         bool                 `json:"ok"`
+	Error      string               `json:"error,omitempty"`
+}
+
+// subjects returns the subject of each of the given certificates.
+func subjects(certs []*x509.Certificate) []string {
+	var results []string
+	for _, cert := range certs {
+		results = append(results, cert.Subject.String())
+	}
+	return results
+}
+
+// rootPool returns a pool of the roots selected by the options. A nil pool is
+// returned if no roots were selected, indicating the system roots should be
+// used.
+func rootPool(client *acmeclient.Client, opts verifyCertOptions) (*x509.CertPool, error) {
+	var roots []*x509.Certificate
+	if opts.rootsPath != "" {
+		certs, err := acmeclient.ReadCertificates(opts.rootsPath)
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, certs...)
+	}
+	if opts.useCABundle {
+		if client.CACert == "" {
+			return nil, errors.New("the shell was started without a -ca bundle")
+		}
+		certs, err := acmeclient.ReadCertificates(client.CACert)
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, certs...)
+	}
+	if opts.pebbleMgmtURL != "" {
+		certs, err := client.PebbleCertificates(opts.pebbleMgmtURL, "roots")
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, certs...)
+	}
+
+	if len(roots) == 0 {
+		return nil, nil
+	}
+	pool := x509.NewCertPool()
+	for _, root := range roots {
+		pool.AddCert(root)
+	}
+	return pool, nil
+}